@@ -0,0 +1,616 @@
+package msgpack
+
+import (
+	"encoding"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// maxDepth is the maximum nesting of arrays and maps, as in encoding/json,
+// so that malicious data can not exhaust the stack.
+const maxDepth = 10000
+
+var (
+	errShortBuffer = errors.New("msgpack: unexpected end of data")
+	errMaxDepth    = fmt.Errorf("msgpack: exceeded max depth of %d", maxDepth)
+)
+
+// Unmarshal decodes the MessagePack encoded data into the value pointed to
+// by v.
+//
+// Decoding into an interface{} stores nil, bool, int64, uint64 for integers
+// beyond int64, float64, string, []byte, []interface{} and
+// map[string]interface{}, or map[interface{}]interface{} if a key is not a
+// string.
+//
+// Values implementing json.Unmarshaler are decoded from the JSON form of
+// the MessagePack value, values implementing encoding.TextUnmarshaler from a
+// string.
+// Arrays and maps may be nested up to a depth of 10000.
+func Unmarshal(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if reflect.Ptr != rv.Kind() || rv.IsNil() {
+		return fmt.Errorf("msgpack: Unmarshal of non-pointer or nil %T", v)
+	}
+
+	d := &decoder{data: data}
+	if err := d.decode(rv.Elem()); nil != err {
+		return err
+	}
+	if d.off != len(d.data) {
+		return fmt.Errorf("msgpack: %d bytes of trailing data", len(d.data)-d.off)
+	}
+	return nil
+}
+
+type decoder struct {
+	data  []byte
+	off   int
+	depth int
+}
+
+// enter is called before decoding the elements of an array or map, leave
+// after.
+func (d *decoder) enter() error {
+	d.depth++
+	if maxDepth < d.depth {
+		return errMaxDepth
+	}
+	return nil
+}
+
+func (d *decoder) leave() {
+	d.depth--
+}
+
+func (d *decoder) peek() (byte, error) {
+	if d.off >= len(d.data) {
+		return 0, errShortBuffer
+	}
+	return d.data[d.off], nil
+}
+
+func (d *decoder) read(n int) ([]byte, error) {
+	if n < 0 || len(d.data)-d.off < n {
+		return nil, errShortBuffer
+	}
+	b := d.data[d.off : d.off+n]
+	d.off += n
+	return b, nil
+}
+
+func (d *decoder) readUint(size int) (uint64, error) {
+	b, err := d.read(size)
+	if nil != err {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	default:
+		return binary.BigEndian.Uint64(b), nil
+	}
+}
+
+// lengthOf returns the length of the str, bin, array or map starting with c,
+// which has already been read.
+func (d *decoder) lengthOf(c byte) (int, error) {
+	var size int
+	switch {
+	case c&0xe0 == codeFixStr:
+		return int(c & 0x1f), nil
+	case c&0xf0 == codeFixArray, c&0xf0 == codeFixMap:
+		return int(c & 0x0f), nil
+	case codeStr8 == c, codeBin8 == c:
+		size = 1
+	case codeStr16 == c, codeBin16 == c, codeArray16 == c, codeMap16 == c:
+		size = 2
+	default:
+		size = 4
+	}
+	n, err := d.readUint(size)
+	if nil != err {
+		return 0, err
+	}
+	if uint64(len(d.data)) < n {
+		return 0, errShortBuffer
+	}
+	return int(n), nil
+}
+
+func isStr(c byte) bool {
+	return c&0xe0 == codeFixStr || codeStr8 == c || codeStr16 == c || codeStr32 == c
+}
+
+func isBin(c byte) bool {
+	return codeBin8 == c || codeBin16 == c || codeBin32 == c
+}
+
+func isArray(c byte) bool {
+	return c&0xf0 == codeFixArray || codeArray16 == c || codeArray32 == c
+}
+
+func isMap(c byte) bool {
+	return c&0xf0 == codeFixMap || codeMap16 == c || codeMap32 == c
+}
+
+func isInt(c byte) bool {
+	return c <= 0x7f || 0xe0 <= c || (codeUint8 <= c && c <= codeInt64)
+}
+
+// readInt reads an integer starting with c, which has already been read.
+// neg is true if the value is negative and has to be taken from n as int64.
+func (d *decoder) readInt(c byte) (n uint64, neg bool, err error) {
+	switch {
+	case c <= 0x7f:
+		return uint64(c), false, nil
+	case 0xe0 <= c:
+		return uint64(int64(int8(c))), true, nil
+	case codeUint8 <= c && c <= codeUint64:
+		n, err = d.readUint(1 << (c - codeUint8))
+		return n, false, err
+	default:
+		size := 1 << (c - codeInt8)
+		if n, err = d.readUint(size); nil != err {
+			return 0, false, err
+		}
+		var i int64
+		switch size {
+		case 1:
+			i = int64(int8(n))
+		case 2:
+			i = int64(int16(n))
+		case 4:
+			i = int64(int32(n))
+		default:
+			i = int64(n)
+		}
+		return uint64(i), i < 0, nil
+	}
+}
+
+// skip advances over the next value.
+func (d *decoder) skip() error {
+	c, err := d.peek()
+	if nil != err {
+		return err
+	}
+	d.off++
+
+	switch {
+	case codeNil == c, codeTrue == c, codeFalse == c:
+		return nil
+	case isInt(c):
+		_, _, err = d.readInt(c)
+		return err
+	case codeFloat32 == c:
+		_, err = d.read(4)
+		return err
+	case codeFloat64 == c:
+		_, err = d.read(8)
+		return err
+	case isStr(c), isBin(c):
+		n, err := d.lengthOf(c)
+		if nil != err {
+			return err
+		}
+		_, err = d.read(n)
+		return err
+	case isArray(c), isMap(c):
+		n, err := d.lengthOf(c)
+		if nil != err {
+			return err
+		}
+		if isMap(c) {
+			n *= 2
+		}
+		if err := d.enter(); nil != err {
+			return err
+		}
+		defer d.leave()
+		for indexI := 0; indexI < n; indexI++ {
+			if err := d.skip(); nil != err {
+				return err
+			}
+		}
+		return nil
+	case codeFixExt1 <= c && c <= codeFixExt16:
+		_, err = d.read(1 + 1<<(c-codeFixExt1))
+		return err
+	case codeExt8 <= c && c <= codeExt32:
+		size := 1 << (c - codeExt8)
+		n, err := d.readUint(size)
+		if nil != err {
+			return err
+		}
+		_, err = d.read(1 + int(n))
+		return err
+	default:
+		return fmt.Errorf("msgpack: invalid code 0x%02x", c)
+	}
+}
+
+func (d *decoder) decode(v reflect.Value) error {
+	if v.Type() == typeOfRawMessage {
+		start := d.off
+		if err := d.skip(); nil != err {
+			return err
+		}
+		v.SetBytes(append(RawMessage(nil), d.data[start:d.off]...))
+		return nil
+	}
+
+	c, err := d.peek()
+	if nil != err {
+		return err
+	}
+	if codeNil == c {
+		d.off++
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return d.decode(v.Elem())
+	case reflect.Interface:
+		if 0 != v.NumMethod() {
+			return fmt.Errorf("msgpack: can not decode into %s", v.Type())
+		}
+		i, err := d.decodeInterface()
+		if nil != err {
+			return err
+		}
+		if nil == i {
+			v.Set(reflect.Zero(v.Type()))
+		} else {
+			v.Set(reflect.ValueOf(i))
+		}
+		return nil
+	}
+
+	if v.CanAddr() {
+		switch u := v.Addr().Interface().(type) {
+		case json.Unmarshaler:
+			return d.decodeJSON(u)
+		case encoding.TextUnmarshaler:
+			if isStr(c) {
+				d.off++
+				n, err := d.lengthOf(c)
+				if nil != err {
+					return err
+				}
+				b, err := d.read(n)
+				if nil != err {
+					return err
+				}
+				return u.UnmarshalText(b)
+			}
+		}
+	}
+
+	d.off++
+	switch {
+	case codeTrue == c, codeFalse == c:
+		if reflect.Bool != v.Kind() {
+			return typeError("bool", v)
+		}
+		v.SetBool(codeTrue == c)
+		return nil
+	case isInt(c):
+		n, neg, err := d.readInt(c)
+		if nil != err {
+			return err
+		}
+		return setInt(v, n, neg)
+	case codeFloat32 == c, codeFloat64 == c:
+		var f float64
+		if codeFloat32 == c {
+			n, err := d.readUint(4)
+			if nil != err {
+				return err
+			}
+			f = float64(math.Float32frombits(uint32(n)))
+		} else {
+			n, err := d.readUint(8)
+			if nil != err {
+				return err
+			}
+			f = math.Float64frombits(n)
+		}
+		if reflect.Float32 != v.Kind() && reflect.Float64 != v.Kind() {
+			return typeError("float", v)
+		}
+		v.SetFloat(f)
+		return nil
+	case isStr(c), isBin(c):
+		n, err := d.lengthOf(c)
+		if nil != err {
+			return err
+		}
+		b, err := d.read(n)
+		if nil != err {
+			return err
+		}
+		switch {
+		case reflect.String == v.Kind():
+			v.SetString(string(b))
+		case reflect.Slice == v.Kind() && reflect.Uint8 == v.Type().Elem().Kind():
+			v.SetBytes(append([]byte(nil), b...))
+		case reflect.Array == v.Kind() && reflect.Uint8 == v.Type().Elem().Kind():
+			if v.Len() != n {
+				return fmt.Errorf("msgpack: can not decode %d bytes into %s", n, v.Type())
+			}
+			reflect.Copy(v, reflect.ValueOf(b))
+		default:
+			return typeError("string", v)
+		}
+		return nil
+	case isArray(c):
+		n, err := d.lengthOf(c)
+		if nil != err {
+			return err
+		}
+		return d.decodeArray(v, n)
+	case isMap(c):
+		n, err := d.lengthOf(c)
+		if nil != err {
+			return err
+		}
+		return d.decodeMap(v, n)
+	default:
+		return fmt.Errorf("msgpack: unsupported code 0x%02x", c)
+	}
+}
+
+// decodeJSON decodes the next value with the UnmarshalJSON method of u.
+func (d *decoder) decodeJSON(u json.Unmarshaler) error {
+	i, err := d.decodeInterface()
+	if nil != err {
+		return err
+	}
+	b, err := json.Marshal(i)
+	if nil != err {
+		return err
+	}
+	return u.UnmarshalJSON(b)
+}
+
+func (d *decoder) decodeArray(v reflect.Value, n int) error {
+	if err := d.enter(); nil != err {
+		return err
+	}
+	defer d.leave()
+	switch v.Kind() {
+	case reflect.Slice:
+		s := reflect.MakeSlice(v.Type(), n, n)
+		for indexI := 0; indexI < n; indexI++ {
+			if err := d.decode(s.Index(indexI)); nil != err {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	case reflect.Array:
+		if v.Len() != n {
+			return fmt.Errorf("msgpack: can not decode %d elements into %s", n, v.Type())
+		}
+		for indexI := 0; indexI < n; indexI++ {
+			if err := d.decode(v.Index(indexI)); nil != err {
+				return err
+			}
+		}
+		return nil
+	default:
+		return typeError("array", v)
+	}
+}
+
+func (d *decoder) decodeMap(v reflect.Value, n int) error {
+	if err := d.enter(); nil != err {
+		return err
+	}
+	defer d.leave()
+	switch v.Kind() {
+	case reflect.Map:
+		t := v.Type()
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(t))
+		}
+		for indexI := 0; indexI < n; indexI++ {
+			key := reflect.New(t.Key()).Elem()
+			if err := d.decode(key); nil != err {
+				return err
+			}
+			value := reflect.New(t.Elem()).Elem()
+			if err := d.decode(value); nil != err {
+				return err
+			}
+			v.SetMapIndex(key, value)
+		}
+		return nil
+	case reflect.Struct:
+		fields := cachedFields(v.Type())
+		for indexI := 0; indexI < n; indexI++ {
+			var name string
+			if err := d.decode(reflect.ValueOf(&name).Elem()); nil != err {
+				return err
+			}
+			f := findField(fields, name)
+			if nil == f {
+				if err := d.skip(); nil != err {
+					return err
+				}
+				continue
+			}
+			fv := v
+			for indexJ, i := range f.index {
+				if 0 < indexJ && reflect.Ptr == fv.Kind() {
+					if fv.IsNil() {
+						fv.Set(reflect.New(fv.Type().Elem()))
+					}
+					fv = fv.Elem()
+				}
+				fv = fv.Field(i)
+			}
+			if err := d.decode(fv); nil != err {
+				return err
+			}
+		}
+		return nil
+	default:
+		return typeError("map", v)
+	}
+}
+
+// findField returns the field of name, preferring an exact match over a case
+// insensitive one.
+func findField(fields []field, name string) *field {
+	var fold *field
+	for indexI := range fields {
+		if fields[indexI].name == name {
+			return &fields[indexI]
+		}
+		if nil == fold && strings.EqualFold(fields[indexI].name, name) {
+			fold = &fields[indexI]
+		}
+	}
+	return fold
+}
+
+func (d *decoder) decodeInterface() (interface{}, error) {
+	c, err := d.peek()
+	if nil != err {
+		return nil, err
+	}
+
+	switch {
+	case codeNil == c:
+		d.off++
+		return nil, nil
+	case codeTrue == c, codeFalse == c:
+		d.off++
+		return codeTrue == c, nil
+	case isInt(c):
+		d.off++
+		n, neg, err := d.readInt(c)
+		if nil != err {
+			return nil, err
+		}
+		if !neg && n > math.MaxInt64 {
+			return n, nil
+		}
+		return int64(n), nil
+	case codeFloat32 == c, codeFloat64 == c:
+		var f float64
+		err := d.decode(reflect.ValueOf(&f).Elem())
+		return f, err
+	case isStr(c):
+		var s string
+		err := d.decode(reflect.ValueOf(&s).Elem())
+		return s, err
+	case isBin(c):
+		var b []byte
+		err := d.decode(reflect.ValueOf(&b).Elem())
+		return b, err
+	case isArray(c):
+		d.off++
+		n, err := d.lengthOf(c)
+		if nil != err {
+			return nil, err
+		}
+		if err = d.enter(); nil != err {
+			return nil, err
+		}
+		defer d.leave()
+		a := make([]interface{}, n)
+		for indexI := 0; indexI < n; indexI++ {
+			if a[indexI], err = d.decodeInterface(); nil != err {
+				return nil, err
+			}
+		}
+		return a, nil
+	case isMap(c):
+		d.off++
+		n, err := d.lengthOf(c)
+		if nil != err {
+			return nil, err
+		}
+		if err = d.enter(); nil != err {
+			return nil, err
+		}
+		defer d.leave()
+		keys := make([]interface{}, n)
+		values := make([]interface{}, n)
+		stringKeys := true
+		for indexI := 0; indexI < n; indexI++ {
+			if keys[indexI], err = d.decodeInterface(); nil != err {
+				return nil, err
+			}
+			if _, ok := keys[indexI].(string); !ok {
+				stringKeys = false
+			}
+			if values[indexI], err = d.decodeInterface(); nil != err {
+				return nil, err
+			}
+		}
+		if stringKeys {
+			m := make(map[string]interface{}, n)
+			for indexI, key := range keys {
+				m[key.(string)] = values[indexI]
+			}
+			return m, nil
+		}
+		m := make(map[interface{}]interface{}, n)
+		for indexI, key := range keys {
+			if nil != key && !reflect.TypeOf(key).Comparable() {
+				return nil, fmt.Errorf("msgpack: map key of type %T is not comparable", key)
+			}
+			m[key] = values[indexI]
+		}
+		return m, nil
+	default:
+		return nil, fmt.Errorf("msgpack: unsupported code 0x%02x", c)
+	}
+}
+
+func setInt(v reflect.Value, n uint64, neg bool) error {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i := int64(n)
+		if (!neg && n > math.MaxInt64) || v.OverflowInt(i) {
+			return fmt.Errorf("msgpack: %d overflows %s", n, v.Type())
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if neg || v.OverflowUint(n) {
+			return fmt.Errorf("msgpack: %d overflows %s", int64(n), v.Type())
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		if neg {
+			v.SetFloat(float64(int64(n)))
+		} else {
+			v.SetFloat(float64(n))
+		}
+	default:
+		return typeError("integer", v)
+	}
+	return nil
+}
+
+func typeError(what string, v reflect.Value) error {
+	return fmt.Errorf("msgpack: can not decode %s into %s", what, v.Type())
+}
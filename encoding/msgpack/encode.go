@@ -0,0 +1,409 @@
+package msgpack
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+var (
+	typeOfJSONMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	typeOfTextMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Marshal returns the MessagePack encoding of v.
+//
+// Structs are encoded as maps keyed by field name, which may be changed with
+// a `msgpack` or, if that is missing, a `json` field tag. []byte is encoded
+// as binary, RawMessage is copied as is.
+//
+// As in encoding/json a value implementing json.Marshaler is encoded as the
+// MessagePack form of its JSON encoding, else a value implementing
+// encoding.TextMarshaler as a string.
+func Marshal(v interface{}) ([]byte, error) {
+	e := &encoder{}
+	if err := e.encode(reflect.ValueOf(v)); nil != err {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) encode(v reflect.Value) error {
+	if !v.IsValid() {
+		e.buf = append(e.buf, codeNil)
+		return nil
+	}
+
+	if v.Type() == typeOfRawMessage {
+		raw := v.Bytes()
+		if 0 == len(raw) {
+			e.buf = append(e.buf, codeNil)
+			return nil
+		}
+		e.buf = append(e.buf, raw...)
+		return nil
+	}
+
+	if (reflect.Ptr == v.Kind() || reflect.Interface == v.Kind()) && v.IsNil() {
+		e.buf = append(e.buf, codeNil)
+		return nil
+	}
+	if marshaler := marshalerOf(v); nil != marshaler {
+		return e.encodeMarshaler(marshaler)
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			e.buf = append(e.buf, codeTrue)
+		} else {
+			e.buf = append(e.buf, codeFalse)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.encodeInt(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.encodeUint(v.Uint())
+	case reflect.Float32:
+		e.buf = append(e.buf, codeFloat32)
+		e.buf = appendUint32(e.buf, math.Float32bits(float32(v.Float())))
+	case reflect.Float64:
+		e.buf = append(e.buf, codeFloat64)
+		e.buf = appendUint64(e.buf, math.Float64bits(v.Float()))
+	case reflect.String:
+		e.encodeString(v.String())
+	case reflect.Slice:
+		if v.IsNil() {
+			e.buf = append(e.buf, codeNil)
+			return nil
+		}
+		if reflect.Uint8 == v.Type().Elem().Kind() {
+			e.encodeBin(v.Bytes())
+			return nil
+		}
+		return e.encodeArray(v)
+	case reflect.Array:
+		if reflect.Uint8 == v.Type().Elem().Kind() {
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			e.encodeBin(b)
+			return nil
+		}
+		return e.encodeArray(v)
+	case reflect.Map:
+		if v.IsNil() {
+			e.buf = append(e.buf, codeNil)
+			return nil
+		}
+		return e.encodeMap(v)
+	case reflect.Struct:
+		return e.encodeStruct(v)
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			e.buf = append(e.buf, codeNil)
+			return nil
+		}
+		return e.encode(v.Elem())
+	default:
+		return fmt.Errorf("msgpack: unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// marshalerOf returns v, or its address if v is addressable, as a
+// json.Marshaler or encoding.TextMarshaler, or nil.
+func marshalerOf(v reflect.Value) interface{} {
+	for _, t := range []reflect.Type{typeOfJSONMarshaler, typeOfTextMarshaler} {
+		if reflect.Interface != v.Kind() && v.Type().Implements(t) {
+			return v.Interface()
+		}
+		if reflect.Ptr != v.Kind() && v.CanAddr() && v.Addr().Type().Implements(t) {
+			return v.Addr().Interface()
+		}
+	}
+	return nil
+}
+
+func (e *encoder) encodeMarshaler(marshaler interface{}) error {
+	if m, ok := marshaler.(json.Marshaler); ok {
+		b, err := m.MarshalJSON()
+		if nil != err {
+			return err
+		}
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber()
+		var i interface{}
+		if err = dec.Decode(&i); nil != err {
+			return fmt.Errorf("msgpack: MarshalJSON of %T: %v", marshaler, err)
+		}
+		return e.encodeJSON(i)
+	}
+	text, err := marshaler.(encoding.TextMarshaler).MarshalText()
+	if nil != err {
+		return err
+	}
+	e.encodeString(string(text))
+	return nil
+}
+
+// encodeJSON encodes a value decoded by encoding/json with UseNumber.
+func (e *encoder) encodeJSON(i interface{}) error {
+	switch v := i.(type) {
+	case json.Number:
+		return e.encodeNumber(v)
+	case []interface{}:
+		e.encodeArrayLen(len(v))
+		for _, element := range v {
+			if err := e.encodeJSON(element); nil != err {
+				return err
+			}
+		}
+		return nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		e.encodeMapLen(len(keys))
+		for _, key := range keys {
+			e.encodeString(key)
+			if err := e.encodeJSON(v[key]); nil != err {
+				return err
+			}
+		}
+		return nil
+	default:
+		return e.encode(reflect.ValueOf(i))
+	}
+}
+
+// encodeNumber encodes n as an integer if it is one, else as a float.
+func (e *encoder) encodeNumber(n json.Number) error {
+	if i, err := strconv.ParseInt(string(n), 10, 64); nil == err {
+		e.encodeInt(i)
+		return nil
+	}
+	if u, err := strconv.ParseUint(string(n), 10, 64); nil == err {
+		e.encodeUint(u)
+		return nil
+	}
+	f, err := n.Float64()
+	if nil != err {
+		return err
+	}
+	e.buf = append(e.buf, codeFloat64)
+	e.buf = appendUint64(e.buf, math.Float64bits(f))
+	return nil
+}
+
+func (e *encoder) encodeInt(n int64) {
+	switch {
+	case 0 <= n:
+		e.encodeUint(uint64(n))
+	case -32 <= n:
+		e.buf = append(e.buf, byte(n))
+	case math.MinInt8 <= n:
+		e.buf = append(e.buf, codeInt8, byte(n))
+	case math.MinInt16 <= n:
+		e.buf = append(e.buf, codeInt16)
+		e.buf = appendUint16(e.buf, uint16(n))
+	case math.MinInt32 <= n:
+		e.buf = append(e.buf, codeInt32)
+		e.buf = appendUint32(e.buf, uint32(n))
+	default:
+		e.buf = append(e.buf, codeInt64)
+		e.buf = appendUint64(e.buf, uint64(n))
+	}
+}
+
+func (e *encoder) encodeUint(n uint64) {
+	switch {
+	case n <= 0x7f:
+		e.buf = append(e.buf, byte(n))
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, codeUint8, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, codeUint16)
+		e.buf = appendUint16(e.buf, uint16(n))
+	case n <= math.MaxUint32:
+		e.buf = append(e.buf, codeUint32)
+		e.buf = appendUint32(e.buf, uint32(n))
+	default:
+		e.buf = append(e.buf, codeUint64)
+		e.buf = appendUint64(e.buf, n)
+	}
+}
+
+func (e *encoder) encodeString(s string) {
+	n := len(s)
+	switch {
+	case n < 32:
+		e.buf = append(e.buf, codeFixStr|byte(n))
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, codeStr8, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, codeStr16)
+		e.buf = appendUint16(e.buf, uint16(n))
+	default:
+		e.buf = append(e.buf, codeStr32)
+		e.buf = appendUint32(e.buf, uint32(n))
+	}
+	e.buf = append(e.buf, s...)
+}
+
+func (e *encoder) encodeBin(b []byte) {
+	n := len(b)
+	switch {
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, codeBin8, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, codeBin16)
+		e.buf = appendUint16(e.buf, uint16(n))
+	default:
+		e.buf = append(e.buf, codeBin32)
+		e.buf = appendUint32(e.buf, uint32(n))
+	}
+	e.buf = append(e.buf, b...)
+}
+
+func (e *encoder) encodeArrayLen(n int) {
+	switch {
+	case n < 16:
+		e.buf = append(e.buf, codeFixArray|byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, codeArray16)
+		e.buf = appendUint16(e.buf, uint16(n))
+	default:
+		e.buf = append(e.buf, codeArray32)
+		e.buf = appendUint32(e.buf, uint32(n))
+	}
+}
+
+func (e *encoder) encodeMapLen(n int) {
+	switch {
+	case n < 16:
+		e.buf = append(e.buf, codeFixMap|byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, codeMap16)
+		e.buf = appendUint16(e.buf, uint16(n))
+	default:
+		e.buf = append(e.buf, codeMap32)
+		e.buf = appendUint32(e.buf, uint32(n))
+	}
+}
+
+func (e *encoder) encodeArray(v reflect.Value) error {
+	e.encodeArrayLen(v.Len())
+	for indexI := 0; indexI < v.Len(); indexI++ {
+		if err := e.encode(v.Index(indexI)); nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *encoder) encodeMap(v reflect.Value) error {
+	keys := v.MapKeys()
+	// Sort string keys so that the encoding is deterministic.
+	if reflect.String == v.Type().Key().Kind() {
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].String() < keys[j].String()
+		})
+	}
+
+	e.encodeMapLen(len(keys))
+	for _, key := range keys {
+		if err := e.encode(key); nil != err {
+			return err
+		}
+		if err := e.encode(v.MapIndex(key)); nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *encoder) encodeStruct(v reflect.Value) error {
+	fields := cachedFields(v.Type())
+
+	values := make([]reflect.Value, 0, len(fields))
+	names := make([]string, 0, len(fields))
+	for _, f := range fields {
+		fv, ok := fieldByIndex(v, f.index)
+		if !ok || (f.omitEmpty && isEmptyValue(fv)) {
+			continue
+		}
+		values = append(values, fv)
+		names = append(names, f.name)
+	}
+
+	e.encodeMapLen(len(values))
+	for indexI, fv := range values {
+		e.encodeString(names[indexI])
+		if err := e.encode(fv); nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldByIndex returns the field of v at index, ok is false if the field is
+// in an embedded struct pointer which is nil.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for indexI, i := range index {
+		if 0 < indexI && reflect.Ptr == v.Kind() {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v, true
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return 0 == v.Len()
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return 0 == v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return 0 == v.Uint()
+	case reflect.Float32, reflect.Float64:
+		return 0 == v.Float()
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+func appendUint16(b []byte, n uint16) []byte {
+	var buf [2]byte
+	binary.BigEndian.PutUint16(buf[:], n)
+	return append(b, buf[:]...)
+}
+
+func appendUint32(b []byte, n uint32) []byte {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], n)
+	return append(b, buf[:]...)
+}
+
+func appendUint64(b []byte, n uint64) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], n)
+	return append(b, buf[:]...)
+}
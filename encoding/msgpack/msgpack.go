@@ -0,0 +1,138 @@
+// Package msgpack implements encoding and decoding of MessagePack as defined
+// in https://github.com/msgpack/msgpack/blob/master/spec.md.
+//
+// The mapping between MessagePack and Go values follows encoding/json,
+// including json.Marshaler, json.Unmarshaler, encoding.TextMarshaler and
+// encoding.TextUnmarshaler. Extension types are not supported.
+package msgpack
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+const (
+	codeFixMap   byte = 0x80
+	codeFixArray byte = 0x90
+	codeFixStr   byte = 0xa0
+	codeNil      byte = 0xc0
+	codeFalse    byte = 0xc2
+	codeTrue     byte = 0xc3
+	codeBin8     byte = 0xc4
+	codeBin16    byte = 0xc5
+	codeBin32    byte = 0xc6
+	codeExt8     byte = 0xc7
+	codeExt16    byte = 0xc8
+	codeExt32    byte = 0xc9
+	codeFloat32  byte = 0xca
+	codeFloat64  byte = 0xcb
+	codeUint8    byte = 0xcc
+	codeUint16   byte = 0xcd
+	codeUint32   byte = 0xce
+	codeUint64   byte = 0xcf
+	codeInt8     byte = 0xd0
+	codeInt16    byte = 0xd1
+	codeInt32    byte = 0xd2
+	codeInt64    byte = 0xd3
+	codeFixExt1  byte = 0xd4
+	codeFixExt16 byte = 0xd8
+	codeStr8     byte = 0xd9
+	codeStr16    byte = 0xda
+	codeStr32    byte = 0xdb
+	codeArray16  byte = 0xdc
+	codeArray32  byte = 0xdd
+	codeMap16    byte = 0xde
+	codeMap32    byte = 0xdf
+)
+
+// RawMessage is a raw encoded MessagePack value.
+// It can be used to delay decoding or to precompute an encoding.
+type RawMessage []byte
+
+var (
+	typeOfRawMessage = reflect.TypeOf(RawMessage(nil))
+)
+
+type field struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+var (
+	fieldsMutex sync.RWMutex
+	fieldsCache = make(map[reflect.Type][]field)
+)
+
+// cachedFields returns the encoded fields of the struct type t.
+func cachedFields(t reflect.Type) []field {
+	fieldsMutex.RLock()
+	fields, ok := fieldsCache[t]
+	fieldsMutex.RUnlock()
+	if ok {
+		return fields
+	}
+
+	fields = typeFields(t, nil, map[reflect.Type]bool{t: true})
+
+	fieldsMutex.Lock()
+	fieldsCache[t] = fields
+	fieldsMutex.Unlock()
+	return fields
+}
+
+// typeFields returns the fields of t, a struct at index. visited are the
+// structs on the path of embedding, which are not promoted again.
+func typeFields(t reflect.Type, index []int, visited map[reflect.Type]bool) []field {
+	fields := make([]field, 0, t.NumField())
+	for indexI := 0; indexI < t.NumField(); indexI++ {
+		sf := t.Field(indexI)
+		name, opts := parseTag(sf)
+		if "-" == name {
+			continue
+		}
+
+		fIndex := make([]int, len(index)+1)
+		copy(fIndex, index)
+		fIndex[len(index)] = indexI
+
+		ft := sf.Type
+		if reflect.Ptr == ft.Kind() {
+			ft = ft.Elem()
+		}
+		// Fields of untagged embedded structs are promoted.
+		if sf.Anonymous && "" == name && reflect.Struct == ft.Kind() {
+			if !visited[ft] {
+				visited[ft] = true
+				fields = append(fields, typeFields(ft, fIndex, visited)...)
+				delete(visited, ft)
+			}
+			continue
+		}
+		if "" != sf.PkgPath {
+			continue
+		}
+
+		if "" == name {
+			name = sf.Name
+		}
+		fields = append(fields, field{
+			name:      name,
+			index:     fIndex,
+			omitEmpty: strings.Contains(opts, "omitempty"),
+		})
+	}
+	return fields
+}
+
+func parseTag(sf reflect.StructField) (name string, opts string) {
+	tag, ok := sf.Tag.Lookup("msgpack")
+	if !ok {
+		tag = sf.Tag.Get("json")
+	}
+	if indexI := strings.IndexByte(tag, ','); 0 <= indexI {
+		return tag[:indexI], tag[indexI+1:]
+	}
+	return tag, ""
+}
@@ -0,0 +1,228 @@
+package msgpack
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMarshalFormats(t *testing.T) {
+	tests := []struct {
+		name string
+		v    interface{}
+		want []byte
+	}{
+		{"nil", nil, []byte{0xc0}},
+		{"true", true, []byte{0xc3}},
+		{"fixint", 5, []byte{0x05}},
+		{"negative fixint", -3, []byte{0xfd}},
+		{"uint8", 200, []byte{0xcc, 0xc8}},
+		{"int16", -1000, []byte{0xd1, 0xfc, 0x18}},
+		{"uint64", uint64(math.MaxUint64), []byte{0xcf, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{"fixstr", "abc", []byte{0xa3, 'a', 'b', 'c'}},
+		{"bin", []byte{1, 2}, []byte{0xc4, 0x02, 0x01, 0x02}},
+		{"fixarray", []int{1, 2}, []byte{0x92, 0x01, 0x02}},
+		{"fixmap", map[string]int{"b": 2, "a": 1}, []byte{0x82, 0xa1, 'a', 0x01, 0xa1, 'b', 0x02}},
+		{"float64", 1.5, []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Marshal(tt.v)
+			if nil != err {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("Marshal() = % x, want % x", got, tt.want)
+			}
+		})
+	}
+}
+
+type inner struct {
+	ID int `json:"id"`
+}
+
+type outer struct {
+	inner
+	Name    string            `msgpack:"name"`
+	Tags    []string          `json:"tags,omitempty"`
+	Attrs   map[string]string `json:"attrs"`
+	Ptr     *int              `json:"ptr"`
+	Skipped string            `json:"-"`
+	Raw     RawMessage        `json:"raw"`
+}
+
+func TestRoundTrip(t *testing.T) {
+	n := 7
+	raw, _ := Marshal([]string{"x"})
+	in := outer{
+		inner: inner{ID: 3},
+		Name:  "host",
+		Attrs: map[string]string{"os": "linux"},
+		Ptr:   &n,
+		Raw:   raw,
+	}
+	b, err := Marshal(in)
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	var out outer
+	if err := Unmarshal(b, &out); nil != err {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("Unmarshal() = %+v, want %+v", out, in)
+	}
+
+	var generic interface{}
+	if err := Unmarshal(b, &generic); nil != err {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"id":    int64(3),
+		"name":  "host",
+		"attrs": map[string]interface{}{"os": "linux"},
+		"ptr":   int64(7),
+		"raw":   []interface{}{"x"},
+	}
+	if !reflect.DeepEqual(generic, want) {
+		t.Errorf("Unmarshal() into interface{} = %#v, want %#v", generic, want)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	var i8 int8
+	if err := Unmarshal([]byte{0xcc, 0xc8}, &i8); nil == err {
+		t.Errorf("Unmarshal() did not detect the overflow")
+	}
+	var u uint
+	if err := Unmarshal([]byte{0xff}, &u); nil == err {
+		t.Errorf("Unmarshal() of a negative int into uint succeeded")
+	}
+	var s string
+	if err := Unmarshal([]byte{0xa3, 'a'}, &s); nil == err {
+		t.Errorf("Unmarshal() of short data succeeded")
+	}
+	if err := Unmarshal([]byte{0x01, 0x02}, &u); nil == err {
+		t.Errorf("Unmarshal() with trailing data succeeded")
+	}
+	if err := Unmarshal([]byte{0x01}, u); nil == err {
+		t.Errorf("Unmarshal() into non-pointer succeeded")
+	}
+}
+
+func TestRawMessage(t *testing.T) {
+	b, _ := Marshal([]interface{}{1, "a", map[string]int{"x": 1}})
+	var raws []RawMessage
+	if err := Unmarshal(b, &raws); nil != err {
+		t.Fatal(err)
+	}
+	if 3 != len(raws) {
+		t.Fatalf("Unmarshal() = %v", raws)
+	}
+	var m map[string]int
+	if err := Unmarshal(raws[2], &m); nil != err || 1 != m["x"] {
+		t.Errorf("Unmarshal() of RawMessage = %v, %v", m, err)
+	}
+}
+
+func TestMaxDepth(t *testing.T) {
+	nested := func(depth int) []byte {
+		return append(bytes.Repeat([]byte{0x91}, depth), 0xc0)
+	}
+
+	var i interface{}
+	if err := Unmarshal(nested(maxDepth), &i); nil != err {
+		t.Errorf("Unmarshal() at max depth = %v", err)
+	}
+	data := nested(maxDepth + 1)
+	if err := Unmarshal(data, &i); errMaxDepth != err {
+		t.Errorf("Unmarshal() into interface{} = %v, want %v", err, errMaxDepth)
+	}
+	var a []interface{}
+	if err := Unmarshal(data, &a); errMaxDepth != err {
+		t.Errorf("Unmarshal() into []interface{} = %v, want %v", err, errMaxDepth)
+	}
+	var raw RawMessage
+	if err := Unmarshal(data, &raw); errMaxDepth != err {
+		t.Errorf("Unmarshal() into RawMessage = %v, want %v", err, errMaxDepth)
+	}
+}
+
+type temperature float64
+
+func (t temperature) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{"celsius": float64(t), "valid": []int{1}})
+}
+
+func (t *temperature) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Celsius float64 `json:"celsius"`
+	}
+	if err := json.Unmarshal(data, &v); nil != err {
+		return err
+	}
+	*t = temperature(v.Celsius)
+	return nil
+}
+
+type marshalers struct {
+	Time time.Time    `json:"time"`
+	IP   net.IP       `json:"ip"`
+	Temp temperature  `json:"temp"`
+	Ptr  *temperature `json:"ptr"`
+}
+
+func TestMarshalers(t *testing.T) {
+	temp := temperature(-3)
+	in := marshalers{
+		Time: time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC),
+		IP:   net.ParseIP("192.0.2.1"),
+		Temp: 21.5,
+		Ptr:  &temp,
+	}
+	b, err := Marshal(in)
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	var m map[string]interface{}
+	if err = Unmarshal(b, &m); nil != err {
+		t.Fatal(err)
+	}
+	if "2026-10-19T12:00:00Z" != m["time"] || "192.0.2.1" != m["ip"] {
+		t.Errorf("Marshal() = %v", m)
+	}
+	if temp, ok := m["temp"].(map[string]interface{}); !ok || 21.5 != temp["celsius"] || !reflect.DeepEqual([]interface{}{int64(1)}, temp["valid"]) {
+		t.Errorf("Marshal() of json.Marshaler = %#v", m["temp"])
+	}
+
+	var out marshalers
+	if err = Unmarshal(b, &out); nil != err {
+		t.Fatal(err)
+	}
+	if !in.Time.Equal(out.Time) || !in.IP.Equal(out.IP) || in.Temp != out.Temp || nil == out.Ptr || temp != *out.Ptr {
+		t.Errorf("Unmarshal() = %+v, want %+v", out, in)
+	}
+}
+
+type recursive struct {
+	*recursive
+	Name string `json:"name"`
+}
+
+func TestRecursiveEmbedding(t *testing.T) {
+	b, err := Marshal(&recursive{Name: "a"})
+	if nil != err {
+		t.Fatal(err)
+	}
+	var out recursive
+	if err = Unmarshal(b, &out); nil != err || "a" != out.Name {
+		t.Errorf("Unmarshal() = %+v, %v", out, err)
+	}
+}
@@ -0,0 +1,27 @@
+package net
+
+// Aggregate returns the smallest list of networks covering exactly the
+// addresses of nets, sorted with IPv4 before IPv6. Adjacent and overlapping
+// networks are merged and contained ones dropped. Networks whose mask is
+// not canonical or does not match their IP are ignored.
+func Aggregate(nets []IPNet) []IPNet {
+	var set IPSet
+	for _, n := range nets {
+		set.AddNet(n)
+	}
+	return set.CIDRs()
+}
+
+// AggregateStrings parses prefixes with ParseCIDROrIP, so that plain
+// addresses are fully masked networks, and aggregates them.
+func AggregateStrings(prefixes []string) ([]IPNet, error) {
+	nets := make([]IPNet, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		_, n, err := ParseCIDROrIP(prefix)
+		if nil != err {
+			return nil, err
+		}
+		nets = append(nets, *n)
+	}
+	return Aggregate(nets), nil
+}
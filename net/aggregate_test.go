@@ -0,0 +1,37 @@
+package net
+
+import (
+	"testing"
+)
+
+func TestAggregate(t *testing.T) {
+	tests := []struct {
+		name     string
+		prefixes []string
+		want     string
+	}{
+		{"adjacent", []string{"10.0.0.0/25", "10.0.0.128/25"}, "10.0.0.0/24"},
+		{"contained", []string{"10.0.0.0/8", "10.1.0.0/16", "10.2.3.4"}, "10.0.0.0/8"},
+		{"overlapping", []string{"10.0.0.0/23", "10.0.1.0/24", "10.0.2.0/24", "10.0.3.0/24"}, "10.0.0.0/22"},
+		{"not aligned", []string{"10.0.1.0/24", "10.0.2.0/24"}, "10.0.1.0/24 10.0.2.0/24"},
+		{"families", []string{"2001:db8:1::/48", "10.0.0.1", "2001:db8::/48", "10.0.0.0"}, "10.0.0.0/31 2001:db8::/47"},
+		{"unmasked", []string{"192.168.1.77/24", "192.168.0.1/24"}, "192.168.0.0/23"},
+		{"empty", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AggregateStrings(tt.prefixes)
+			if nil != err {
+				t.Fatal(err)
+			}
+			if cidrStrings(got) != tt.want {
+				t.Errorf("AggregateStrings() = %s, want %s", cidrStrings(got), tt.want)
+			}
+		})
+	}
+
+	if _, err := AggregateStrings([]string{"10.0.0.0/33"}); nil == err {
+		t.Errorf("AggregateStrings() of invalid prefix error = nil")
+	}
+}
@@ -0,0 +1,121 @@
+package net
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrOverflow is returned by IP arithmetic whose result is outside the
+	// address space of the family of the IP.
+	ErrOverflow = errors.New("Net: address overflow")
+)
+
+// normalized returns the IP as 4-bytes for IPv4, as 16-bytes for IPv6, or
+// nil if it is not valid.
+func (i IP) normalized() net.IP {
+	if ip4 := i.IP.To4(); nil != ip4 {
+		return ip4
+	}
+	if net.IPv6len == len(i.IP) {
+		return i.IP
+	}
+	return nil
+}
+
+// ipToBig returns ip as an unsigned integer.
+func ipToBig(ip net.IP) *big.Int {
+	return new(big.Int).SetBytes(ip)
+}
+
+// bigToIP returns n as an IP of size bytes, or ErrOverflow if it does not
+// fit.
+func bigToIP(n *big.Int, size int) (IP, error) {
+	if 0 > n.Sign() || size*8 < n.BitLen() {
+		return IP{}, ErrOverflow
+	}
+	ip := make(net.IP, size)
+	n.FillBytes(ip)
+	return IP{IP: ip}, nil
+}
+
+// Add returns the IP n addresses after the IP in the same family, n may be
+// negative. It returns ErrOverflow if the result is out of range.
+func (i IP) Add(n int64) (IP, error) {
+	return i.AddBig(big.NewInt(n))
+}
+
+// AddBig is Add with an arbitrary large n, as needed for IPv6.
+func (i IP) AddBig(n *big.Int) (IP, error) {
+	ip := i.normalized()
+	if nil == ip {
+		return IP{}, fmt.Errorf("Net: invalid IP %v", i.IP)
+	}
+	return bigToIP(ipToBig(ip).Add(ipToBig(ip), n), len(ip))
+}
+
+// Sub returns the IP n addresses before the IP in the same family. It
+// returns ErrOverflow if the result is out of range.
+func (i IP) Sub(n int64) (IP, error) {
+	return i.AddBig(new(big.Int).Neg(big.NewInt(n)))
+}
+
+// Next returns the IP after the IP, or ErrOverflow for the last address of
+// the family.
+func (i IP) Next() (IP, error) {
+	return i.Add(1)
+}
+
+// Prev returns the IP before the IP, or ErrOverflow for the first address
+// of the family.
+func (i IP) Prev() (IP, error) {
+	return i.Add(-1)
+}
+
+// Distance returns other minus the IP, which is negative if other comes
+// first. Both IPs must be of the same family.
+func (i IP) Distance(other IP) (*big.Int, error) {
+	ip, otherIP := i.normalized(), other.normalized()
+	if nil == ip || nil == otherIP {
+		return nil, fmt.Errorf("Net: invalid IP %v or %v", i.IP, other.IP)
+	}
+	if len(ip) != len(otherIP) {
+		return nil, fmt.Errorf("Net: %v and %v are not of the same family", i.IP, other.IP)
+	}
+	return new(big.Int).Sub(ipToBig(otherIP), ipToBig(ip)), nil
+}
+
+// Compare returns -1, 0 or 1 if the IP is less than, equal to or greater
+// than other. Invalid IPs come first, then IPv4 before IPv6 addresses, an
+// IPv4 address equals its 16-byte form. Equal addresses are ordered by
+// zone, no zone first.
+func (i IP) Compare(other IP) int {
+	ip, otherIP := i.normalized(), other.normalized()
+	if len(ip) != len(otherIP) {
+		if len(ip) < len(otherIP) {
+			return -1
+		}
+		return 1
+	}
+	if c := bytes.Compare(ip, otherIP); 0 != c {
+		return c
+	}
+	return strings.Compare(i.Zone, other.Zone)
+}
+
+// IPs is a slice of IPs sorted by IP.Compare.
+type IPs []IP
+
+func (s IPs) Len() int           { return len(s) }
+func (s IPs) Less(i, j int) bool { return -1 == s[i].Compare(s[j]) }
+func (s IPs) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// SortIPs sorts ips in increasing order of IP.Compare.
+func SortIPs(ips []IP) {
+	sort.Sort(IPs(ips))
+}
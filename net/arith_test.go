@@ -0,0 +1,83 @@
+package net
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestIPAdd(t *testing.T) {
+	tests := []struct {
+		name    string
+		ip      string
+		n       int64
+		want    string
+		wantErr bool
+	}{
+		{"ipv4", "10.0.0.255", 1, "10.0.1.0", false},
+		{"ipv4 negative", "10.0.1.0", -1, "10.0.0.255", false},
+		{"ipv4 overflow", "255.255.255.255", 1, "", true},
+		{"ipv4 underflow", "0.0.0.0", -1, "", true},
+		{"ipv6", "2001:db8::ffff", 1, "2001:db8::1:0", false},
+		{"ipv6 overflow", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", 1, "", true},
+		{"ipv6 underflow", "::", -1, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MustParseIP(tt.ip).Add(tt.n)
+			if (nil != err) != tt.wantErr {
+				t.Fatalf("Add() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if ErrOverflow != err {
+					t.Errorf("Add() error = %v, want ErrOverflow", err)
+				}
+				return
+			}
+			if tt.want != got.String() {
+				t.Errorf("Add() = %s, want %s", got, tt.want)
+			}
+			back, err := got.Sub(tt.n)
+			if nil != err || tt.ip != back.String() {
+				t.Errorf("Sub() = %s, %v, want %s", back, err, tt.ip)
+			}
+		})
+	}
+
+	ip := MustParseIP("10.0.0.1")
+	if next, _ := ip.Next(); "10.0.0.2" != next.String() || 4 != len(next.IP) {
+		t.Errorf("Next() = %s", next)
+	}
+	if prev, _ := ip.Prev(); "10.0.0.0" != prev.String() {
+		t.Errorf("Prev() = %s", prev)
+	}
+}
+
+func TestIPDistanceCompare(t *testing.T) {
+	d, err := MustParseIP("10.0.0.1").Distance(MustParseIP("10.0.1.1"))
+	if nil != err || 0 != d.Cmp(big.NewInt(256)) {
+		t.Errorf("Distance() = %v, %v", d, err)
+	}
+	d, err = MustParseIP("::").Distance(MustParseIP("::1:0:0:0:0"))
+	if nil != err || 0 != d.Cmp(new(big.Int).Lsh(big.NewInt(1), 64)) {
+		t.Errorf("Distance() = %v, %v", d, err)
+	}
+	if _, err = MustParseIP("10.0.0.1").Distance(MustParseIP("::1")); nil == err {
+		t.Errorf("Distance() of mixed families error = nil")
+	}
+
+	ips := []IP{
+		MustParseIP("::1"),
+		MustParseIP("10.0.0.2"),
+		{},
+		MustParseIP("10.0.0.10"),
+		MustParseIP("1.2.3.4"),
+	}
+	SortIPs(ips)
+	want := []string{"<nil>", "1.2.3.4", "10.0.0.2", "10.0.0.10", "::1"}
+	for indexI, ip := range ips {
+		if want[indexI] != ip.String() {
+			t.Errorf("SortIPs()[%d] = %s, want %s", indexI, ip, want[indexI])
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net"
 
+	lunet "github.com/LOAFLE/util-go/net"
 	"github.com/LOAFLE/util-go/net/converter"
 )
 
@@ -48,9 +49,10 @@ func (cr *cidrRangeIPv4) Range() []net.IP {
 // (startIP > endIP) return nil
 // (nil != startIP && nil != endIP) return (startIP ~ endIP) + include - exclude
 // (nil == startIP || nil == endIP) return include - exclude
+// include and exclude must be IPv4, the result is sorted in ascending order
 func (cr *cidrRangeIPv4) Ranges(startIP net.IP, endIP net.IP, include []net.IP, exclude []net.IP) ([]net.IP, error) {
 
-	res := make(map[int32]bool)
+	var set lunet.IPSet
 
 	if nil != startIP && nil != endIP {
 		if !cr.Contains(startIP) {
@@ -59,37 +61,32 @@ func (cr *cidrRangeIPv4) Ranges(startIP net.IP, endIP net.IP, include []net.IP,
 		if !cr.Contains(endIP) {
 			return nil, fmt.Errorf("CIDR Range: CIDR not contains end ip[%v]", endIP)
 		}
-		sNum := converter.IPv4ToInt(startIP.To4())
-		eNum := converter.IPv4ToInt(endIP.To4())
-		if sNum > eNum {
+		if err := set.AddRange(lunet.IP{IP: startIP}, lunet.IP{IP: endIP}); nil != err {
 			return nil, fmt.Errorf("CIDR Range: Start IP[%v] must smaller then End IP[%v]", startIP, endIP)
 		}
-		for i := sNum; i <= eNum; i++ {
-			res[i] = true
-		}
 	}
 
-	if nil != include {
-		for _, in := range include {
-			iNum := converter.IPv4ToInt(in.To4())
-			if _, ok := res[iNum]; !ok {
-				res[iNum] = true
-			}
+	for _, in := range include {
+		if nil == in.To4() {
+			return nil, fmt.Errorf("CIDR Range: include ip[%v] is not IPv4", in)
+		}
+		if err := set.AddIP(lunet.IP{IP: in}); nil != err {
+			return nil, fmt.Errorf("CIDR Range: invalid include ip[%v]", in)
 		}
 	}
 
-	if nil != exclude {
-		for _, ex := range exclude {
-			iNum := converter.IPv4ToInt(ex.To4())
-			if _, ok := res[iNum]; ok {
-				delete(res, iNum)
-			}
+	for _, ex := range exclude {
+		if nil == ex.To4() {
+			return nil, fmt.Errorf("CIDR Range: exclude ip[%v] is not IPv4", ex)
+		}
+		if err := set.RemoveIP(lunet.IP{IP: ex}); nil != err {
+			return nil, fmt.Errorf("CIDR Range: invalid exclude ip[%v]", ex)
 		}
 	}
 
 	r := make([]net.IP, 0)
-	for k, _ := range res {
-		r = append(r, converter.IntToIPv4(k))
+	for it := set.IPs(); it.Next(); {
+		r = append(r, it.IP().IP)
 	}
 
 	return r, nil
@@ -0,0 +1,47 @@
+// +build ignore
+
+// gen_special writes special_registry.go with the CSV files of the IANA
+// special-purpose address registries in iana. Run it with go generate.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"strings"
+)
+
+var registries = []struct {
+	name string
+	file string
+}{
+	{"ipv4SpecialRegistry", "iana/iana-ipv4-special-registry.csv"},
+	{"ipv6SpecialRegistry", "iana/iana-ipv6-special-registry.csv"},
+}
+
+func main() {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by gen_special.go from the files in iana; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package net\n")
+	for _, registry := range registries {
+		data, err := ioutil.ReadFile(registry.file)
+		if nil != err {
+			log.Fatal(err)
+		}
+		if strings.ContainsAny(string(data), "`\r") {
+			log.Fatalf("%s contains a back quote or a carriage return", registry.file)
+		}
+		fmt.Fprintf(&buf, "\n// %s is %s.\n", registry.name, registry.file)
+		fmt.Fprintf(&buf, "const %s = `%s`\n", registry.name, data)
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if nil != err {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile("special_registry.go", src, 0644); nil != err {
+		log.Fatal(err)
+	}
+}
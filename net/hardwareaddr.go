@@ -0,0 +1,74 @@
+package net
+
+import (
+	"encoding/json"
+	"net"
+)
+
+// Sub class net.HardwareAddr so that we can add marshalling and database
+// support.
+type HardwareAddr struct {
+	net.HardwareAddr
+}
+
+// ParseMAC parses a MAC address in one of the formats of net.ParseMAC.
+func ParseMAC(s string) (*HardwareAddr, error) {
+	hw, err := net.ParseMAC(s)
+	if nil != err {
+		return nil, err
+	}
+	return &HardwareAddr{hw}, nil
+}
+
+// MustParseMAC parses the string into a HardwareAddr.
+func MustParseMAC(s string) HardwareAddr {
+	hw, err := ParseMAC(s)
+	if nil != err {
+		panic(err)
+	}
+	return *hw
+}
+
+// MarshalText encodes the address as colon separated hex digits. A nil
+// address is encoded as empty text.
+func (h HardwareAddr) MarshalText() ([]byte, error) {
+	return []byte(h.HardwareAddr.String()), nil
+}
+
+// UnmarshalText decodes an address in one of the formats of net.ParseMAC,
+// empty text gives a nil address.
+func (h *HardwareAddr) UnmarshalText(text []byte) error {
+	if 0 == len(text) {
+		h.HardwareAddr = nil
+		return nil
+	}
+	hw, err := net.ParseMAC(string(text))
+	if nil != err {
+		return err
+	}
+	h.HardwareAddr = hw
+	return nil
+}
+
+// MarshalJSON encodes the address as a JSON string, or as null if it is
+// nil.
+func (h HardwareAddr) MarshalJSON() ([]byte, error) {
+	if nil == h.HardwareAddr {
+		return []byte("null"), nil
+	}
+	return json.Marshal(h.HardwareAddr.String())
+}
+
+// UnmarshalJSON decodes an address from a JSON string. null and the empty
+// string give a nil address.
+func (h *HardwareAddr) UnmarshalJSON(data []byte) error {
+	if "null" == string(data) {
+		h.HardwareAddr = nil
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); nil != err {
+		return err
+	}
+	return h.UnmarshalText([]byte(s))
+}
@@ -0,0 +1,180 @@
+package net
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/LOAFLE/util-go/net/converter"
+	"github.com/LOAFLE/util-go/net/gateway"
+)
+
+// Interface is a network interface of this host with its addresses.
+type Interface struct {
+	Name         string
+	Index        int
+	MTU          int
+	Flags        net.Flags
+	HardwareAddr net.HardwareAddr
+	// MAC is the hardware address as converter.MacToUint64 returns it, or 0
+	// if the interface has no hardware address of at most 8 bytes.
+	MAC uint64
+	// Addrs are the addresses of the interface with the masks of their
+	// networks. IPv4 addresses are 4-bytes.
+	Addrs []IPNet
+}
+
+// InterfaceFilter reports whether Interfaces returns an interface.
+type InterfaceFilter func(iface *Interface) bool
+
+// InterfaceUp accepts interfaces which are up.
+func InterfaceUp(iface *Interface) bool {
+	return 0 != iface.Flags&net.FlagUp
+}
+
+// InterfaceNonLoopback accepts interfaces which are not loopback interfaces.
+func InterfaceNonLoopback(iface *Interface) bool {
+	return 0 == iface.Flags&net.FlagLoopback
+}
+
+// InterfaceHasIPv4 accepts interfaces with an IPv4 address.
+func InterfaceHasIPv4(iface *Interface) bool {
+	return 0 < len(iface.IPv4Addrs())
+}
+
+// Interfaces returns the network interfaces of this host accepted by all
+// filters. Interfaces whose addresses cannot be read are skipped.
+func Interfaces(filters ...InterfaceFilter) ([]Interface, error) {
+	netIfaces, err := net.Interfaces()
+	if nil != err {
+		return nil, err
+	}
+
+	ifaces := make([]Interface, 0, len(netIfaces))
+	for _, netIface := range netIfaces {
+		addrs, err := netIface.Addrs()
+		if nil != err {
+			continue
+		}
+		ifaces = append(ifaces, interfaceOf(netIface, addrs))
+	}
+	return filterInterfaces(ifaces, filters), nil
+}
+
+// filterInterfaces returns the interfaces accepted by all filters.
+func filterInterfaces(ifaces []Interface, filters []InterfaceFilter) []Interface {
+	accepted := make([]Interface, 0, len(ifaces))
+NEXT_INTERFACE:
+	for indexI := range ifaces {
+		for _, filter := range filters {
+			if !filter(&ifaces[indexI]) {
+				continue NEXT_INTERFACE
+			}
+		}
+		accepted = append(accepted, ifaces[indexI])
+	}
+	return accepted
+}
+
+// interfaceOf returns netIface with the addresses addrs.
+func interfaceOf(netIface net.Interface, addrs []net.Addr) Interface {
+	iface := Interface{
+		Name:         netIface.Name,
+		Index:        netIface.Index,
+		MTU:          netIface.MTU,
+		Flags:        netIface.Flags,
+		HardwareAddr: netIface.HardwareAddr,
+	}
+	if 0 < len(netIface.HardwareAddr) {
+		// Addresses longer than 8 bytes, as of InfiniBand, do not fit.
+		if mac, err := converter.MacToUint64(netIface.HardwareAddr); nil == err {
+			iface.MAC = mac
+		}
+	}
+
+	for _, addr := range addrs {
+		n, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		ipNet := IPNet{*n}
+		if ip4 := n.IP.To4(); nil != ip4 && net.IPv4len == len(n.Mask) {
+			ipNet.IP = ip4
+		}
+		iface.Addrs = append(iface.Addrs, ipNet)
+	}
+	return iface
+}
+
+// IPv4Addrs returns the IPv4 addresses of the interface.
+func (i *Interface) IPv4Addrs() []IPNet {
+	return i.addrsOf(4)
+}
+
+// IPv6Addrs returns the IPv6 addresses of the interface.
+func (i *Interface) IPv6Addrs() []IPNet {
+	return i.addrsOf(6)
+}
+
+func (i *Interface) addrsOf(version int) []IPNet {
+	var addrs []IPNet
+	for _, addr := range i.Addrs {
+		if version == addr.Version() {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
+
+// DefaultRouteInterface returns the interface which carries the default
+// route found by gateway.DiscoverGateway, and the gateway. An IPv6
+// link-local gateway has the name of the interface as zone.
+func DefaultRouteInterface() (*Interface, IP, error) {
+	gatewayIP, name, err := gateway.DiscoverGateway()
+	if nil != err {
+		return nil, IP{}, err
+	}
+	ifaces, err := Interfaces()
+	if nil != err {
+		return nil, IP{}, err
+	}
+	iface := routeInterface(ifaces, gatewayIP, name)
+	if nil == iface {
+		return nil, IP{}, fmt.Errorf("Net: no interface for gateway %v on %s", gatewayIP, name)
+	}
+	return iface, gatewayOf(iface, gatewayIP), nil
+}
+
+// gatewayOf returns gatewayIP of a route on iface, with the name of iface
+// as zone if gatewayIP is an IPv6 link-local address.
+func gatewayOf(iface *Interface, gatewayIP net.IP) IP {
+	addr := net.IPAddr{IP: gatewayIP}
+	if nil == gatewayIP.To4() && gatewayIP.IsLinkLocalUnicast() {
+		addr.Zone = iface.Name
+	}
+	return IPFromIPAddr(addr)
+}
+
+// routeInterface returns the interface of a route to gatewayIP. name is the
+// name of the interface, or one of its addresses as on Windows. Without a
+// match by name the interface whose network contains gatewayIP is returned.
+func routeInterface(ifaces []Interface, gatewayIP net.IP, name string) *Interface {
+	nameIP := net.ParseIP(name)
+	for indexI := range ifaces {
+		if name == ifaces[indexI].Name {
+			return &ifaces[indexI]
+		}
+		for _, addr := range ifaces[indexI].Addrs {
+			if nil != nameIP && nameIP.Equal(addr.IP) {
+				return &ifaces[indexI]
+			}
+		}
+	}
+	for indexI := range ifaces {
+		for _, addr := range ifaces[indexI].Addrs {
+			if nil != gatewayIP && addr.Contains(gatewayIP) {
+				return &ifaces[indexI]
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package net
+
+import (
+	"net"
+	"strings"
+	"testing"
+)
+
+func testInterfaces() []Interface {
+	return []Interface{
+		{
+			Name:  "lo",
+			Index: 1,
+			Flags: net.FlagUp | net.FlagLoopback,
+			Addrs: []IPNet{MustParseCIDR("127.0.0.1/8"), MustParseCIDR("::1/128")},
+		},
+		{
+			Name:  "eth0",
+			Index: 2,
+			Flags: net.FlagUp | net.FlagBroadcast,
+			Addrs: []IPNet{MustParseCIDR("fe80::1/64")},
+		},
+		{
+			Name:  "eth1",
+			Index: 3,
+			Flags: net.FlagUp | net.FlagBroadcast,
+			Addrs: []IPNet{MustParseCIDR("192.168.1.10/24")},
+		},
+		{
+			Name:  "eth2",
+			Index: 4,
+			Addrs: []IPNet{MustParseCIDR("10.0.0.5/8")},
+		},
+	}
+}
+
+func TestInterfaceFilters(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters []InterfaceFilter
+		want    string
+	}{
+		{"none", nil, "lo,eth0,eth1,eth2"},
+		{"up", []InterfaceFilter{InterfaceUp}, "lo,eth0,eth1"},
+		{"non-loopback", []InterfaceFilter{InterfaceNonLoopback}, "eth0,eth1,eth2"},
+		{"ipv4", []InterfaceFilter{InterfaceHasIPv4}, "lo,eth1,eth2"},
+		{"all", []InterfaceFilter{InterfaceUp, InterfaceNonLoopback, InterfaceHasIPv4}, "eth1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var names []string
+			for _, iface := range filterInterfaces(testInterfaces(), tt.filters) {
+				names = append(names, iface.Name)
+			}
+			if tt.want != strings.Join(names, ",") {
+				t.Errorf("filterInterfaces() = %v, want %s", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterfaceOf(t *testing.T) {
+	netIface := net.Interface{
+		Index:        2,
+		MTU:          1500,
+		Name:         "eth0",
+		HardwareAddr: net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x55},
+		Flags:        net.FlagUp,
+	}
+	addrs := []net.Addr{
+		&net.IPNet{IP: net.ParseIP("192.168.1.10"), Mask: net.CIDRMask(24, 32)},
+		&net.IPNet{IP: net.ParseIP("fe80::1"), Mask: net.CIDRMask(64, 128)},
+		&net.IPAddr{IP: net.ParseIP("10.0.0.1")},
+	}
+
+	iface := interfaceOf(netIface, addrs)
+	if "eth0" != iface.Name || 0x001122334455 != iface.MAC || 2 != len(iface.Addrs) {
+		t.Fatalf("interfaceOf() = %+v", iface)
+	}
+	if v4 := iface.IPv4Addrs(); 1 != len(v4) || net.IPv4len != len(v4[0].IP) || "192.168.1.10/24" != v4[0].String() {
+		t.Errorf("IPv4Addrs() = %v", v4)
+	}
+	if v6 := iface.IPv6Addrs(); 1 != len(v6) || "fe80::1/64" != v6[0].String() {
+		t.Errorf("IPv6Addrs() = %v", v6)
+	}
+}
+
+func TestGatewayOf(t *testing.T) {
+	iface := &testInterfaces()[1]
+	tests := []struct {
+		gateway string
+		want    string
+	}{
+		{"fe80::2", "fe80::2%eth0"},
+		{"2001:db8::1", "2001:db8::1"},
+		{"169.254.0.1", "169.254.0.1"},
+		{"192.168.1.1", "192.168.1.1"},
+	}
+
+	for _, tt := range tests {
+		if ip := gatewayOf(iface, net.ParseIP(tt.gateway)); tt.want != ip.String() {
+			t.Errorf("gatewayOf(%s) = %v, want %s", tt.gateway, ip, tt.want)
+		}
+	}
+}
+
+func TestRouteInterface(t *testing.T) {
+	tests := []struct {
+		name    string
+		gateway string
+		iface   string
+		want    string
+	}{
+		{"by name", "192.168.1.1", "eth1", "eth1"},
+		{"by address", "192.168.1.1", "10.0.0.5", "eth2"},
+		{"by network", "192.168.1.1", "en9", "eth1"},
+		{"ipv6 link-local", "fe80::2", "", "eth0"},
+		{"none", "172.16.0.1", "en9", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			iface := routeInterface(testInterfaces(), net.ParseIP(tt.gateway), tt.iface)
+			if "" == tt.want {
+				if nil != iface {
+					t.Errorf("routeInterface() = %s, want nil", iface.Name)
+				}
+				return
+			}
+			if nil == iface || tt.want != iface.Name {
+				t.Errorf("routeInterface() = %v, want %s", iface, tt.want)
+			}
+		})
+	}
+}
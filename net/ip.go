@@ -1,26 +1,89 @@
 package net
 
 import (
+	"encoding/json"
+	"fmt"
 	"net"
+	"strings"
 )
 
 // Sub class net.IP so that we can add JSON marshalling and unmarshalling.
+// IP has more than one field, literals must be keyed as in IP{IP: ip}.
 type IP struct {
 	net.IP
+	// Zone is the scope of an IPv6 address as in "fe80::1%eth0", or "".
+	Zone string
 }
 
-// ParseIP returns an IP from a string
+// ParseIP returns an IP from a string. IPv4 addresses, also IPv4-mapped
+// IPv6 addresses such as "::ffff:192.0.2.1", are returned as 4-bytes, see
+// ParseIPExact. An IPv6 address may have a zone as in "fe80::1%eth0".
 func ParseIP(ip string) *IP {
-	addr := net.ParseIP(ip)
-	if addr == nil {
+	addr, zone := splitZone(ip)
+	parsed := net.ParseIP(addr)
+	if parsed == nil {
 		return nil
 	}
 	// Always return IPv4 values as 4-bytes to be consistent with IPv4 IPNet
 	// representations.
-	if addr4 := addr.To4(); addr4 != nil {
-		addr = addr4
+	if addr4 := parsed.To4(); addr4 != nil {
+		if "" != zone {
+			return nil
+		}
+		parsed = addr4
 	}
-	return &IP{addr}
+	return &IP{IP: parsed, Zone: zone}
+}
+
+// splitZone splits an address as in "fe80::1%eth0" into the address and
+// the zone. An empty zone is kept, so that the address does not parse.
+func splitZone(s string) (addr, zone string) {
+	indexI := strings.LastIndexByte(s, '%')
+	if 0 > indexI || indexI == len(s)-1 {
+		return s, ""
+	}
+	return s[:indexI], s[indexI+1:]
+}
+
+// String returns the textual form of the IP followed by "%" and its zone,
+// if any. A nil IP is "<nil>" whatever its zone.
+func (i IP) String() string {
+	if nil == i.IP || "" == i.Zone {
+		return i.IP.String()
+	}
+	return i.IP.String() + "%" + i.Zone
+}
+
+// EqualZone reports whether the IP and other are the same address in the
+// same zone. Equal of net.IP ignores zones.
+func (i IP) EqualZone(other IP) bool {
+	return i.Zone == other.Zone && i.IP.Equal(other.IP)
+}
+
+// IPAddr returns the IP with its zone as a net.IPAddr.
+func (i IP) IPAddr() *net.IPAddr {
+	return &net.IPAddr{IP: i.IP, Zone: i.Zone}
+}
+
+// UDPAddr returns the IP with its zone and port as a net.UDPAddr.
+func (i IP) UDPAddr(port int) *net.UDPAddr {
+	return &net.UDPAddr{IP: i.IP, Port: port, Zone: i.Zone}
+}
+
+// IPFromIPAddr returns the IP and zone of a net.IPAddr. IPv4 addresses are
+// returned as 4-bytes.
+func IPFromIPAddr(addr net.IPAddr) IP {
+	ip := IP{IP: addr.IP, Zone: addr.Zone}
+	if ip4 := addr.IP.To4(); nil != ip4 {
+		ip.IP = ip4
+	}
+	return ip
+}
+
+// IPFromUDPAddr returns the IP with its zone and the port of a
+// net.UDPAddr. IPv4 addresses are returned as 4-bytes.
+func IPFromUDPAddr(addr net.UDPAddr) (IP, int) {
+	return IPFromIPAddr(net.IPAddr{IP: addr.IP, Zone: addr.Zone}), addr.Port
 }
 
 // Version returns the IP version for an IP, or 0 if the IP is not valid.
@@ -62,3 +125,102 @@ func MustParseIP(i string) IP {
 	}
 	return ip
 }
+
+// MarshalText encodes the IP in its textual form. A nil IP is encoded as
+// empty text.
+func (i IP) MarshalText() ([]byte, error) {
+	if 0 == len(i.IP) {
+		return []byte(""), nil
+	}
+	if net.IPv4len != len(i.IP) && net.IPv6len != len(i.IP) {
+		return nil, &net.AddrError{Err: "invalid IP address", Addr: fmt.Sprintf("% x", []byte(i.IP))}
+	}
+	return []byte(i.String()), nil
+}
+
+// UnmarshalText decodes an IP from its textual form as ParseIP does, empty
+// text gives a nil IP.
+func (i *IP) UnmarshalText(text []byte) error {
+	if 0 == len(text) {
+		i.IP, i.Zone = nil, ""
+		return nil
+	}
+	ip := ParseIP(string(text))
+	if nil == ip {
+		return &net.ParseError{Type: "IP address", Text: string(text)}
+	}
+	*i = *ip
+	return nil
+}
+
+// MarshalJSON encodes the IP as a JSON string, or as null if it is nil.
+func (i IP) MarshalJSON() ([]byte, error) {
+	if nil == i.IP {
+		return []byte("null"), nil
+	}
+	text, err := i.MarshalText()
+	if nil != err {
+		return nil, err
+	}
+	return json.Marshal(string(text))
+}
+
+// UnmarshalJSON decodes an IP from a JSON string. null and the empty
+// string give a nil IP.
+func (i *IP) UnmarshalJSON(data []byte) error {
+	if "null" == string(data) {
+		i.IP, i.Zone = nil, ""
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); nil != err {
+		return err
+	}
+	return i.UnmarshalText([]byte(s))
+}
+
+// MarshalBinary encodes the IP as 4 bytes for IPv4, 16 bytes followed by
+// the zone for IPv6 and no bytes if it is nil. It is also used by
+// encoding/gob.
+func (i IP) MarshalBinary() ([]byte, error) {
+	switch len(i.IP) {
+	case 0:
+		if "" != i.Zone {
+			return nil, &net.AddrError{Err: "zone without IP address", Addr: i.String()}
+		}
+		return []byte{}, nil
+	case net.IPv4len, net.IPv6len:
+		if addr4 := i.IP.To4(); nil != addr4 {
+			if "" != i.Zone {
+				return nil, &net.AddrError{Err: "IPv4 address with zone", Addr: i.String()}
+			}
+			return append([]byte(nil), addr4...), nil
+		}
+		return append(append([]byte(nil), i.IP...), i.Zone...), nil
+	default:
+		return nil, &net.AddrError{Err: "invalid IP address", Addr: fmt.Sprintf("% x", []byte(i.IP))}
+	}
+}
+
+// UnmarshalBinary decodes an IP encoded by MarshalBinary.
+func (i *IP) UnmarshalBinary(data []byte) error {
+	switch {
+	case 0 == len(data):
+		i.IP, i.Zone = nil, ""
+	case net.IPv4len == len(data):
+		i.IP, i.Zone = net.IP(append([]byte(nil), data...)), ""
+	case net.IPv6len <= len(data):
+		addr := net.IP(append([]byte(nil), data[:net.IPv6len]...))
+		zone := string(data[net.IPv6len:])
+		if addr4 := addr.To4(); nil != addr4 {
+			if "" != zone {
+				return fmt.Errorf("Net: IPv4 address with zone %q", zone)
+			}
+			addr = addr4
+		}
+		i.IP, i.Zone = addr, zone
+	default:
+		return fmt.Errorf("Net: invalid IP length %d", len(data))
+	}
+	return nil
+}
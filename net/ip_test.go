@@ -0,0 +1,213 @@
+package net
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"net"
+	"testing"
+)
+
+func TestIPMarshal(t *testing.T) {
+	tests := []struct {
+		name string
+		ip   IP
+		json string
+		len  int
+	}{
+		{"ipv4", MustParseIP("192.168.1.1"), `"192.168.1.1"`, net.IPv4len},
+		{"ipv4 16-byte", IP{IP: net.ParseIP("10.0.0.1")}, `"10.0.0.1"`, net.IPv4len},
+		{"ipv6", MustParseIP("2001:db8::1"), `"2001:db8::1"`, net.IPv6len},
+		{"nil", IP{}, `null`, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.ip)
+			if nil != err {
+				t.Fatal(err)
+			}
+			if tt.json != string(data) {
+				t.Errorf("MarshalJSON() = %s, want %s", data, tt.json)
+			}
+			var ip IP
+			if err := json.Unmarshal(data, &ip); nil != err {
+				t.Fatal(err)
+			}
+			if tt.len != len(ip.IP) || !ip.Equal(tt.ip.IP) {
+				t.Errorf("UnmarshalJSON() = %v (%d bytes)", ip, len(ip.IP))
+			}
+
+			data, err = ip.MarshalBinary()
+			if nil != err {
+				t.Fatal(err)
+			}
+			if tt.len != len(data) {
+				t.Errorf("MarshalBinary() = %d bytes, want %d", len(data), tt.len)
+			}
+
+			var buf bytes.Buffer
+			if err := gob.NewEncoder(&buf).Encode(tt.ip); nil != err {
+				t.Fatal(err)
+			}
+			ip = IP{}
+			if err := gob.NewDecoder(&buf).Decode(&ip); nil != err {
+				t.Fatal(err)
+			}
+			if tt.len != len(ip.IP) || !ip.Equal(tt.ip.IP) {
+				t.Errorf("gob = %v (%d bytes)", ip, len(ip.IP))
+			}
+		})
+	}
+
+	var ip IP
+	if err := json.Unmarshal([]byte(`""`), &ip); nil != err || nil != ip.IP {
+		t.Errorf(`UnmarshalJSON("") = %v, %v`, ip, err)
+	}
+	if err := json.Unmarshal([]byte(`"300.1.1.1"`), &ip); nil == err {
+		t.Errorf("UnmarshalJSON() of invalid IP error = nil")
+	}
+	if err := ip.UnmarshalBinary([]byte{1, 2, 3}); nil == err {
+		t.Errorf("UnmarshalBinary() of 3 bytes error = nil")
+	}
+}
+
+func TestIPZone(t *testing.T) {
+	ip := ParseIP("fe80::1%eth0")
+	if nil == ip || "eth0" != ip.Zone || "fe80::1%eth0" != ip.String() {
+		t.Fatalf("ParseIP() = %v", ip)
+	}
+	for _, s := range []string{"192.168.1.1%eth0", "fe80::1%"} {
+		if nil != ParseIP(s) {
+			t.Errorf("ParseIP(%q) != nil", s)
+		}
+	}
+	if parsed, n, err := ParseCIDROrIP("fe80::1%eth0"); nil != err || !parsed.EqualZone(*ip) || "fe80::1/128" != n.String() {
+		t.Errorf("ParseCIDROrIP() = %v, %v, %v", parsed, n, err)
+	}
+	cleared := *ip
+	if err := json.Unmarshal([]byte("null"), &cleared); nil != err || nil != cleared.IP || "" != cleared.Zone {
+		t.Errorf("UnmarshalJSON(null) = %#v, %v", cleared, err)
+	}
+	for _, src := range []interface{}{nil, "", []byte{}} {
+		cleared = *ip
+		if err := cleared.Scan(src); nil != err || nil != cleared.IP || "" != cleared.Zone {
+			t.Errorf("Scan(%#v) = %#v, %v", src, cleared, err)
+		}
+	}
+	nullIP := NullIP{IP: *ip, Valid: true}
+	if err := nullIP.Scan(""); nil != err || nil != nullIP.IP.IP || "" != nullIP.IP.Zone {
+		t.Errorf("NullIP.Scan(\"\") = %#v, %v", nullIP, err)
+	}
+	if "<nil>" != (IP{Zone: "eth0"}).String() {
+		t.Errorf("String() of nil IP with zone = %s", IP{Zone: "eth0"})
+	}
+
+	data, err := ip.MarshalBinary()
+	if nil != err || net.IPv6len+len("eth0") != len(data) {
+		t.Fatalf("MarshalBinary() = %v, %v", data, err)
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(ip); nil != err {
+		t.Fatal(err)
+	}
+	var gobIP IP
+	if err := gob.NewDecoder(&buf).Decode(&gobIP); nil != err || !gobIP.EqualZone(*ip) {
+		t.Errorf("gob = %v, %v", gobIP, err)
+	}
+	if _, err := (IP{IP: net.ParseIP("10.0.0.1"), Zone: "eth0"}).MarshalBinary(); nil == err {
+		t.Errorf("MarshalBinary() of IPv4 with zone error = nil")
+	}
+	if err := gobIP.UnmarshalBinary(append(net.ParseIP("10.0.0.1"), "eth0"...)); nil == err {
+		t.Errorf("UnmarshalBinary() of IPv4 with zone error = nil")
+	}
+
+	data, err = json.Marshal(MustParseIP("fe80::1%eth0"))
+	if nil != err || `"fe80::1%eth0"` != string(data) {
+		t.Fatalf("MarshalJSON() = %s, %v", data, err)
+	}
+	var decoded IP
+	if err = json.Unmarshal(data, &decoded); nil != err || !decoded.EqualZone(*ip) {
+		t.Errorf("UnmarshalJSON() = %v, %v", decoded, err)
+	}
+	if decoded.EqualZone(MustParseIP("fe80::1%eth1")) || !decoded.Equal(MustParseIP("fe80::1%eth1").IP) {
+		t.Errorf("EqualZone() of other zone")
+	}
+	if -1 != MustParseIP("fe80::1").Compare(*ip) || -1 != ip.Compare(MustParseIP("fe80::1%eth1")) {
+		t.Errorf("Compare() does not order zones")
+	}
+
+	udpAddr := ip.UDPAddr(53)
+	if "[fe80::1%eth0]:53" != udpAddr.String() {
+		t.Errorf("UDPAddr() = %s", udpAddr)
+	}
+	if back, port := IPFromUDPAddr(*udpAddr); !back.EqualZone(*ip) || 53 != port {
+		t.Errorf("IPFromUDPAddr() = %v, %d", back, port)
+	}
+	if back := IPFromIPAddr(net.IPAddr{IP: net.ParseIP("10.0.0.1")}); net.IPv4len != len(back.IP) || "" != back.Zone {
+		t.Errorf("IPFromIPAddr() = %v", back)
+	}
+	if "fe80::1%eth0" != ip.IPAddr().String() {
+		t.Errorf("IPAddr() = %s", ip.IPAddr())
+	}
+}
+
+func TestIPNetMarshal(t *testing.T) {
+	tests := []struct {
+		name string
+		n    IPNet
+		json string
+		len  int
+	}{
+		{"ipv4", MustParseCIDR("192.168.1.10/24"), `"192.168.1.10/24"`, net.IPv4len + 1},
+		{"ipv6", MustParseNetwork("2001:db8::/32"), `"2001:db8::/32"`, net.IPv6len + 1},
+		{"zero", IPNet{}, `null`, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.n)
+			if nil != err {
+				t.Fatal(err)
+			}
+			if tt.json != string(data) {
+				t.Errorf("MarshalJSON() = %s, want %s", data, tt.json)
+			}
+			var n IPNet
+			if err := json.Unmarshal(data, &n); nil != err {
+				t.Fatal(err)
+			}
+			if !n.IP.Equal(tt.n.IP) || !bytes.Equal(n.Mask, tt.n.Mask) {
+				t.Errorf("UnmarshalJSON() = %v", n)
+			}
+			if 0 != tt.len && tt.len-1 != len(n.IP) {
+				t.Errorf("UnmarshalJSON() IP has %d bytes", len(n.IP))
+			}
+
+			var buf bytes.Buffer
+			if err := gob.NewEncoder(&buf).Encode(tt.n); nil != err {
+				t.Fatal(err)
+			}
+			n = IPNet{}
+			if err := gob.NewDecoder(&buf).Decode(&n); nil != err {
+				t.Fatal(err)
+			}
+			if !n.IP.Equal(tt.n.IP) || !bytes.Equal(n.Mask, tt.n.Mask) {
+				t.Errorf("gob = %v", n)
+			}
+			data, _ = n.MarshalBinary()
+			if tt.len != len(data) {
+				t.Errorf("MarshalBinary() = %d bytes, want %d", len(data), tt.len)
+			}
+		})
+	}
+
+	invalid := IPNet{net.IPNet{IP: net.IP{10, 0, 0, 0}, Mask: net.IPMask{255, 0, 255, 0}}}
+	if _, err := json.Marshal(invalid); nil == err {
+		t.Errorf("MarshalJSON() of non-canonical mask error = nil")
+	}
+	var n IPNet
+	if err := n.UnmarshalBinary([]byte{10, 0, 0, 0, 33}); nil == err {
+		t.Errorf("UnmarshalBinary() of /33 error = nil")
+	}
+}
@@ -1,7 +1,10 @@
 package net
 
 import (
+	"encoding/json"
+	"fmt"
 	"net"
+	"strconv"
 )
 
 // Sub class net.IPNet so that we can add JSON marshalling and unmarshalling.
@@ -20,6 +23,7 @@ func (i *IPNet) Version() int {
 }
 
 // IsNetOverlap is a utility function that returns true if the two subnet have an overlap.
+// Unlike Overlaps it also compares networks with non-canonical masks.
 func (i *IPNet) IsNetOverlap(n net.IPNet) bool {
 	return n.Contains(i.IP) || i.Contains(n.IP)
 }
@@ -35,7 +39,7 @@ func ParseCIDR(c string) (*IP, *IPNet, error) {
 	if netIPNet == nil || e != nil {
 		return nil, nil, e
 	}
-	ip := &IP{netIP}
+	ip := &IP{IP: netIP}
 	ipnet := &IPNet{*netIPNet}
 
 	// The base golang net library always uses a 4-byte IPv4 address in an
@@ -51,7 +55,9 @@ func ParseCIDR(c string) (*IP, *IPNet, error) {
 }
 
 // Parse a CIDR or an IP address and return the IP, CIDR or error.  If an IP address
-// string is supplied, then the CIDR returned is the fully masked IP address (i.e /32 or /128)
+// string is supplied, then the CIDR returned is the fully masked IP address (i.e /32 or /128).
+// An IPNet has no zone, the zone of an address as in "fe80::1%eth0" is kept
+// on the returned IP only.
 func ParseCIDROrIP(c string) (*IP, *IPNet, error) {
 	// First try parsing as a CIDR.
 	ip, cidr, err := ParseCIDR(c)
@@ -76,7 +82,7 @@ func ParseCIDROrIP(c string) (*IP, *IPNet, error) {
 // String returns a friendly name for the network.  The standard net package
 // implements String() on the pointer, which means it will not be invoked on a
 // struct type, so we re-implement on the struct type.
-func (i *IPNet) String() string {
+func (i IPNet) String() string {
 	ip := &i.IPNet
 	return ip.String()
 }
@@ -103,3 +109,115 @@ func MustParseCIDR(c string) IPNet {
 	n.Mask = cidr.Mask
 	return n
 }
+
+// isZero reports whether the IPNet has neither an IP nor a mask.
+func (i *IPNet) isZero() bool {
+	return 0 == len(i.IP) && 0 == len(i.Mask)
+}
+
+// prefix returns the IP of the IPNet, as 4-bytes for IPv4, and the length of
+// its mask, which must be canonical and match the IP.
+func (i *IPNet) prefix() (net.IP, int, error) {
+	ip := i.IP
+	if ip4 := ip.To4(); nil != ip4 && net.IPv4len == len(i.Mask) {
+		ip = ip4
+	}
+	ones, bits := i.Mask.Size()
+	if 0 == bits || len(ip)*8 != bits {
+		return nil, 0, fmt.Errorf("Net: invalid IPNet %s", i.String())
+	}
+	return ip, ones, nil
+}
+
+// MarshalText encodes the IPNet in CIDR notation, keeping the host bits of
+// the IP. An IPNet without IP and mask is encoded as empty text.
+func (i IPNet) MarshalText() ([]byte, error) {
+	if i.isZero() {
+		return []byte(""), nil
+	}
+	ip, ones, err := i.prefix()
+	if nil != err {
+		return nil, err
+	}
+	return []byte(ip.String() + "/" + strconv.Itoa(ones)), nil
+}
+
+// UnmarshalText decodes an IPNet from CIDR notation without masking the IP.
+// IPv4 addresses are stored as 4-bytes, empty text gives the zero IPNet.
+func (i *IPNet) UnmarshalText(text []byte) error {
+	if 0 == len(text) {
+		i.IP, i.Mask = nil, nil
+		return nil
+	}
+	ip, cidr, err := ParseCIDR(string(text))
+	if nil != err {
+		return err
+	}
+	i.IP = ip.IP
+	i.Mask = cidr.Mask
+	return nil
+}
+
+// MarshalJSON encodes the IPNet as a JSON string in CIDR notation, or as
+// null if it has neither IP nor mask.
+func (i IPNet) MarshalJSON() ([]byte, error) {
+	if i.isZero() {
+		return []byte("null"), nil
+	}
+	text, err := i.MarshalText()
+	if nil != err {
+		return nil, err
+	}
+	return json.Marshal(string(text))
+}
+
+// UnmarshalJSON decodes an IPNet from a JSON string in CIDR notation. null
+// and the empty string give the zero IPNet.
+func (i *IPNet) UnmarshalJSON(data []byte) error {
+	if "null" == string(data) {
+		i.IP, i.Mask = nil, nil
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); nil != err {
+		return err
+	}
+	return i.UnmarshalText([]byte(s))
+}
+
+// MarshalBinary encodes the IPNet as its IP, 4 bytes for IPv4 and 16 bytes
+// for IPv6, followed by the prefix length, or as no bytes if it has neither
+// IP nor mask. It is also used by encoding/gob.
+func (i IPNet) MarshalBinary() ([]byte, error) {
+	if i.isZero() {
+		return []byte{}, nil
+	}
+	ip, ones, err := i.prefix()
+	if nil != err {
+		return nil, err
+	}
+	data := make([]byte, 0, len(ip)+1)
+	data = append(data, ip...)
+	return append(data, byte(ones)), nil
+}
+
+// UnmarshalBinary decodes an IPNet encoded by MarshalBinary.
+func (i *IPNet) UnmarshalBinary(data []byte) error {
+	var ip net.IP
+	switch len(data) {
+	case 0:
+		i.IP, i.Mask = nil, nil
+		return nil
+	case net.IPv4len + 1, net.IPv6len + 1:
+		ip = net.IP(append([]byte(nil), data[:len(data)-1]...))
+	default:
+		return fmt.Errorf("Net: invalid IPNet length %d", len(data))
+	}
+	ones := int(data[len(data)-1])
+	if len(ip)*8 < ones {
+		return fmt.Errorf("Net: invalid prefix length %d for %d-byte IP", ones, len(ip))
+	}
+	i.IP = ip
+	i.Mask = net.CIDRMask(ones, len(ip)*8)
+	return nil
+}
@@ -0,0 +1,131 @@
+package net
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// IPRange is the range of addresses from First to Last, both included.
+type IPRange struct {
+	First IP
+	Last  IP
+}
+
+// ParseIPRange parses a range as "10.0.0.5-10.0.1.200", spaces around "-"
+// are allowed, or a single address.
+func ParseIPRange(s string) (*IPRange, error) {
+	first, last := s, s
+	if indexI := strings.IndexByte(s, '-'); 0 <= indexI {
+		first, last = s[:indexI], s[indexI+1:]
+	}
+	r := &IPRange{}
+	if err := r.First.UnmarshalText([]byte(strings.TrimSpace(first))); nil != err {
+		return nil, err
+	}
+	if err := r.Last.UnmarshalText([]byte(strings.TrimSpace(last))); nil != err {
+		return nil, err
+	}
+	if _, _, err := rangeOf(r.First, r.Last); nil != err {
+		return nil, err
+	}
+	return r, nil
+}
+
+// RangeToCIDRs returns the smallest list of networks covering exactly the
+// addresses from start to end, which must be of the same family.
+func RangeToCIDRs(start IP, end IP) ([]IPNet, error) {
+	size, r, err := rangeOf(start, end)
+	if nil != err {
+		return nil, err
+	}
+	return intervalToCIDRs(size, r), nil
+}
+
+// CIDRToRange returns the range from the first to the last address of n.
+func CIDRToRange(n IPNet) (IPRange, error) {
+	size, r, err := netRangeOf(n)
+	if nil != err {
+		return IPRange{}, err
+	}
+	return IPRange{First: r.first.ip(size), Last: r.last.ip(size)}, nil
+}
+
+// isZero reports whether the range has neither a first nor a last IP.
+func (r IPRange) isZero() bool {
+	return 0 == len(r.First.IP) && 0 == len(r.Last.IP)
+}
+
+// CIDRs returns the smallest list of networks covering exactly the range.
+func (r IPRange) CIDRs() ([]IPNet, error) {
+	return RangeToCIDRs(r.First, r.Last)
+}
+
+// Contains reports whether ip is in the range.
+func (r IPRange) Contains(ip IP) bool {
+	addr := ip.normalized()
+	_, iv, err := rangeOf(r.First, r.Last)
+	if nil == addr || nil != err || len(addr) != len(r.First.normalized()) {
+		return false
+	}
+	u := uint128Of(addr)
+	return 0 <= u.cmp(iv.first) && 0 >= u.cmp(iv.last)
+}
+
+func (r IPRange) String() string {
+	return r.First.String() + "-" + r.Last.String()
+}
+
+// MarshalText encodes the range as "first-last". A range without IPs is
+// encoded as empty text.
+func (r IPRange) MarshalText() ([]byte, error) {
+	if r.isZero() {
+		return []byte(""), nil
+	}
+	if _, _, err := rangeOf(r.First, r.Last); nil != err {
+		return nil, err
+	}
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText decodes a range in a form of ParseIPRange, empty text gives
+// a range without IPs.
+func (r *IPRange) UnmarshalText(text []byte) error {
+	if 0 == len(text) {
+		r.First, r.Last = IP{}, IP{}
+		return nil
+	}
+	parsed, err := ParseIPRange(string(text))
+	if nil != err {
+		return err
+	}
+	*r = *parsed
+	return nil
+}
+
+// MarshalJSON encodes the range as a JSON string, or as null if it has no
+// IPs.
+func (r IPRange) MarshalJSON() ([]byte, error) {
+	if r.isZero() {
+		return []byte("null"), nil
+	}
+	text, err := r.MarshalText()
+	if nil != err {
+		return nil, err
+	}
+	return json.Marshal(string(text))
+}
+
+// UnmarshalJSON decodes a range from a JSON string. null and the empty
+// string give a range without IPs.
+func (r *IPRange) UnmarshalJSON(data []byte) error {
+	if "null" == string(data) {
+		r.First, r.Last = IP{}, IP{}
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); nil != err {
+		return fmt.Errorf("Net: IPRange must be a JSON string: %v", err)
+	}
+	return r.UnmarshalText([]byte(s))
+}
@@ -0,0 +1,76 @@
+package net
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRangeToCIDRs(t *testing.T) {
+	tests := []struct {
+		start   string
+		end     string
+		want    string
+		wantErr bool
+	}{
+		{"10.0.0.5", "10.0.1.200", "10.0.0.5/32 10.0.0.6/31 10.0.0.8/29 10.0.0.16/28 10.0.0.32/27 10.0.0.64/26 10.0.0.128/25 10.0.1.0/25 10.0.1.128/26 10.0.1.192/29 10.0.1.200/32", false},
+		{"0.0.0.0", "255.255.255.255", "0.0.0.0/0", false},
+		{"10.0.0.1", "10.0.0.1", "10.0.0.1/32", false},
+		{"2001:db8::", "2001:db8::1:ffff", "2001:db8::/111", false},
+		{"::1", "::2", "::1/128 ::2/128", false},
+		{"10.0.0.2", "10.0.0.1", "", true},
+		{"10.0.0.1", "::1", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.start+"-"+tt.end, func(t *testing.T) {
+			got, err := RangeToCIDRs(MustParseIP(tt.start), MustParseIP(tt.end))
+			if (nil != err) != tt.wantErr {
+				t.Fatalf("RangeToCIDRs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if cidrStrings(got) != tt.want {
+				t.Errorf("RangeToCIDRs() = %s, want %s", cidrStrings(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestCIDRToRange(t *testing.T) {
+	r, err := CIDRToRange(MustParseCIDR("10.0.0.77/24"))
+	if nil != err || "10.0.0.0-10.0.0.255" != r.String() {
+		t.Errorf("CIDRToRange() = %s, %v", r, err)
+	}
+	r, err = CIDRToRange(MustParseNetwork("2001:db8::/32"))
+	if nil != err || "2001:db8::-2001:db8:ffff:ffff:ffff:ffff:ffff:ffff" != r.String() {
+		t.Errorf("CIDRToRange() = %s, %v", r, err)
+	}
+	if !r.Contains(MustParseIP("2001:db8::1")) || r.Contains(MustParseIP("2001:db9::")) || r.Contains(MustParseIP("10.0.0.1")) {
+		t.Errorf("Contains() is wrong for %s", r)
+	}
+}
+
+func TestIPRangeMarshal(t *testing.T) {
+	var r IPRange
+	if err := json.Unmarshal([]byte(`"10.0.0.5 - 10.0.1.200"`), &r); nil != err {
+		t.Fatal(err)
+	}
+	if 4 != len(r.First.IP) || "10.0.1.200" != r.Last.String() {
+		t.Errorf("UnmarshalJSON() = %s", r)
+	}
+	data, err := json.Marshal(r)
+	if nil != err || `"10.0.0.5-10.0.1.200"` != string(data) {
+		t.Errorf("MarshalJSON() = %s, %v", data, err)
+	}
+
+	if err := r.UnmarshalText([]byte("2001:db8::1")); nil != err || "2001:db8::1-2001:db8::1" != r.String() {
+		t.Errorf("UnmarshalText() of single IP = %s, %v", r, err)
+	}
+	if data, _ = json.Marshal(IPRange{}); "null" != string(data) {
+		t.Errorf("MarshalJSON() of zero range = %s", data)
+	}
+	if err := json.Unmarshal([]byte(`null`), &r); nil != err || !r.isZero() {
+		t.Errorf("UnmarshalJSON(null) = %s, %v", r, err)
+	}
+	if err := r.UnmarshalText([]byte("10.0.0.9-10.0.0.1")); nil == err {
+		t.Errorf("UnmarshalText() of reversed range error = nil")
+	}
+}
@@ -0,0 +1,344 @@
+package net
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"sort"
+)
+
+// interval is an IPRange of one family as integers.
+type interval struct {
+	first, last uint128
+}
+
+// IPSet is a set of IPv4 and IPv6 addresses stored as sorted, disjoint
+// ranges, so that its size depends on the number of ranges only.
+// The zero value is an empty set.
+type IPSet struct {
+	v4 []interval
+	v6 []interval
+}
+
+// rangeOf returns the interval from first to last and the size of their
+// IPs in bytes.
+func rangeOf(first IP, last IP) (int, interval, error) {
+	firstIP, lastIP := first.normalized(), last.normalized()
+	if nil == firstIP || nil == lastIP {
+		return 0, interval{}, fmt.Errorf("Net: invalid range from %v to %v", first.IP, last.IP)
+	}
+	if len(firstIP) != len(lastIP) {
+		return 0, interval{}, fmt.Errorf("Net: %v and %v are not of the same family", first.IP, last.IP)
+	}
+	r := interval{first: uint128Of(firstIP), last: uint128Of(lastIP)}
+	if 0 < r.first.cmp(r.last) {
+		return 0, interval{}, fmt.Errorf("Net: start IP %v of range is greater than end IP %v", first.IP, last.IP)
+	}
+	return len(firstIP), r, nil
+}
+
+// netRangeOf returns the interval of the addresses of n and the size of its
+// IP in bytes.
+func netRangeOf(n IPNet) (int, interval, error) {
+	ip, ones, err := n.prefix()
+	if nil != err {
+		return 0, interval{}, err
+	}
+	bits := len(ip) * 8
+	first := uint128Of(ip.Mask(net.CIDRMask(ones, bits)))
+	return len(ip), interval{first: first, last: first.add(hostMask(bits - ones))}, nil
+}
+
+// touches reports whether an interval ending at last overlaps or is
+// adjacent to an interval starting at first.
+func touches(last uint128, first uint128) bool {
+	return 0 >= first.cmp(last) || last.addOne() == first
+}
+
+func addInterval(list []interval, r interval) []interval {
+	result := make([]interval, 0, len(list)+1)
+	indexI := 0
+	for ; indexI < len(list) && !touches(list[indexI].last, r.first); indexI++ {
+		result = append(result, list[indexI])
+	}
+	for ; indexI < len(list) && touches(r.last, list[indexI].first); indexI++ {
+		if 0 > list[indexI].first.cmp(r.first) {
+			r.first = list[indexI].first
+		}
+		if 0 < list[indexI].last.cmp(r.last) {
+			r.last = list[indexI].last
+		}
+	}
+	result = append(result, r)
+	return append(result, list[indexI:]...)
+}
+
+func removeInterval(list []interval, r interval) []interval {
+	result := make([]interval, 0, len(list)+1)
+	for _, iv := range list {
+		if 0 > iv.last.cmp(r.first) || 0 < iv.first.cmp(r.last) {
+			result = append(result, iv)
+			continue
+		}
+		if 0 > iv.first.cmp(r.first) {
+			result = append(result, interval{first: iv.first, last: r.first.subOne()})
+		}
+		if 0 < iv.last.cmp(r.last) {
+			result = append(result, interval{first: r.last.addOne(), last: iv.last})
+		}
+	}
+	return result
+}
+
+func intersectIntervals(a []interval, b []interval) []interval {
+	var result []interval
+	for indexI, indexJ := 0, 0; indexI < len(a) && indexJ < len(b); {
+		first, last := a[indexI].first, a[indexI].last
+		if 0 < b[indexJ].first.cmp(first) {
+			first = b[indexJ].first
+		}
+		if 0 > b[indexJ].last.cmp(last) {
+			last = b[indexJ].last
+		}
+		if 0 >= first.cmp(last) {
+			result = append(result, interval{first: first, last: last})
+		}
+		if 0 > a[indexI].last.cmp(b[indexJ].last) {
+			indexI++
+		} else {
+			indexJ++
+		}
+	}
+	return result
+}
+
+func (s *IPSet) family(size int) *[]interval {
+	if net.IPv4len == size {
+		return &s.v4
+	}
+	return &s.v6
+}
+
+// AddIP adds ip to the set.
+func (s *IPSet) AddIP(ip IP) error {
+	return s.AddRange(ip, ip)
+}
+
+// AddRange adds the addresses from first to last to the set.
+func (s *IPSet) AddRange(first IP, last IP) error {
+	size, r, err := rangeOf(first, last)
+	if nil != err {
+		return err
+	}
+	list := s.family(size)
+	*list = addInterval(*list, r)
+	return nil
+}
+
+// AddNet adds the addresses of n to the set.
+func (s *IPSet) AddNet(n IPNet) error {
+	size, r, err := netRangeOf(n)
+	if nil != err {
+		return err
+	}
+	list := s.family(size)
+	*list = addInterval(*list, r)
+	return nil
+}
+
+// RemoveIP removes ip from the set.
+func (s *IPSet) RemoveIP(ip IP) error {
+	return s.RemoveRange(ip, ip)
+}
+
+// RemoveRange removes the addresses from first to last from the set.
+func (s *IPSet) RemoveRange(first IP, last IP) error {
+	size, r, err := rangeOf(first, last)
+	if nil != err {
+		return err
+	}
+	list := s.family(size)
+	*list = removeInterval(*list, r)
+	return nil
+}
+
+// RemoveNet removes the addresses of n from the set.
+func (s *IPSet) RemoveNet(n IPNet) error {
+	size, r, err := netRangeOf(n)
+	if nil != err {
+		return err
+	}
+	list := s.family(size)
+	*list = removeInterval(*list, r)
+	return nil
+}
+
+// Union returns the addresses which are in the set or in other.
+func (s *IPSet) Union(other *IPSet) *IPSet {
+	result := s.clone()
+	for _, r := range other.v4 {
+		result.v4 = addInterval(result.v4, r)
+	}
+	for _, r := range other.v6 {
+		result.v6 = addInterval(result.v6, r)
+	}
+	return result
+}
+
+// Intersection returns the addresses which are in the set and in other.
+func (s *IPSet) Intersection(other *IPSet) *IPSet {
+	return &IPSet{
+		v4: intersectIntervals(s.v4, other.v4),
+		v6: intersectIntervals(s.v6, other.v6),
+	}
+}
+
+// Difference returns the addresses which are in the set but not in other.
+func (s *IPSet) Difference(other *IPSet) *IPSet {
+	result := s.clone()
+	for _, r := range other.v4 {
+		result.v4 = removeInterval(result.v4, r)
+	}
+	for _, r := range other.v6 {
+		result.v6 = removeInterval(result.v6, r)
+	}
+	return result
+}
+
+func (s *IPSet) clone() *IPSet {
+	return &IPSet{
+		v4: append([]interval(nil), s.v4...),
+		v6: append([]interval(nil), s.v6...),
+	}
+}
+
+// Contains reports whether ip is in the set.
+func (s *IPSet) Contains(ip IP) bool {
+	addr := ip.normalized()
+	if nil == addr {
+		return false
+	}
+	list := *s.family(len(addr))
+	u := uint128Of(addr)
+	indexI := sort.Search(len(list), func(i int) bool {
+		return 0 <= list[i].last.cmp(u)
+	})
+	return indexI < len(list) && 0 <= u.cmp(list[indexI].first)
+}
+
+// IsEmpty reports whether the set has no addresses.
+func (s *IPSet) IsEmpty() bool {
+	return 0 == len(s.v4) && 0 == len(s.v6)
+}
+
+// Size returns the number of addresses in the set.
+func (s *IPSet) Size() *big.Int {
+	size := new(big.Int)
+	for _, list := range [][]interval{s.v4, s.v6} {
+		for _, r := range list {
+			size.Add(size, r.last.sub(r.first).big())
+			size.Add(size, big.NewInt(1))
+		}
+	}
+	return size
+}
+
+// Ranges returns the disjoint ranges of the set in increasing order, IPv4
+// before IPv6.
+func (s *IPSet) Ranges() []IPRange {
+	ranges := make([]IPRange, 0, len(s.v4)+len(s.v6))
+	for _, r := range s.v4 {
+		ranges = append(ranges, IPRange{First: r.first.ip(net.IPv4len), Last: r.last.ip(net.IPv4len)})
+	}
+	for _, r := range s.v6 {
+		ranges = append(ranges, IPRange{First: r.first.ip(net.IPv6len), Last: r.last.ip(net.IPv6len)})
+	}
+	return ranges
+}
+
+// CIDRs returns the smallest list of networks covering exactly the
+// addresses of the set, in increasing order.
+func (s *IPSet) CIDRs() []IPNet {
+	var cidrs []IPNet
+	for _, r := range s.v4 {
+		cidrs = append(cidrs, intervalToCIDRs(net.IPv4len, r)...)
+	}
+	for _, r := range s.v6 {
+		cidrs = append(cidrs, intervalToCIDRs(net.IPv6len, r)...)
+	}
+	return cidrs
+}
+
+// intervalToCIDRs splits r into the largest aligned networks.
+func intervalToCIDRs(size int, r interval) []IPNet {
+	bits := size * 8
+	var cidrs []IPNet
+	for start := r.first; ; {
+		k := start.trailingZeros()
+		if bits < k {
+			k = bits
+		}
+		for 0 < k && 0 < start.add(hostMask(k)).cmp(r.last) {
+			k--
+		}
+		cidrs = append(cidrs, IPNet{net.IPNet{IP: start.ip(size).IP, Mask: net.CIDRMask(bits-k, bits)}})
+
+		end := start.add(hostMask(k))
+		if end == r.last {
+			return cidrs
+		}
+		start = end.addOne()
+	}
+}
+
+// IPs returns an iterator over the addresses of the set in increasing
+// order, IPv4 before IPv6.
+func (s *IPSet) IPs() *IPIterator {
+	it := &IPIterator{}
+	for _, r := range s.v4 {
+		it.ranges = append(it.ranges, sizedInterval{size: net.IPv4len, interval: r})
+	}
+	for _, r := range s.v6 {
+		it.ranges = append(it.ranges, sizedInterval{size: net.IPv6len, interval: r})
+	}
+	if 0 < len(it.ranges) {
+		it.next = it.ranges[0].first
+	}
+	return it
+}
+
+type sizedInterval struct {
+	size int
+	interval
+}
+
+// IPIterator iterates over the addresses of an IPSet.
+type IPIterator struct {
+	ranges  []sizedInterval
+	index   int
+	next    uint128
+	current IP
+}
+
+// Next advances to the next address and reports whether there is one.
+func (it *IPIterator) Next() bool {
+	if it.index >= len(it.ranges) {
+		return false
+	}
+	r := it.ranges[it.index]
+	it.current = it.next.ip(r.size)
+	if it.next == r.last {
+		it.index++
+		if it.index < len(it.ranges) {
+			it.next = it.ranges[it.index].first
+		}
+	} else {
+		it.next = it.next.addOne()
+	}
+	return true
+}
+
+// IP returns the current address.
+func (it *IPIterator) IP() IP {
+	return it.current
+}
@@ -0,0 +1,104 @@
+package net
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func cidrStrings(cidrs []IPNet) string {
+	s := make([]string, len(cidrs))
+	for indexI, n := range cidrs {
+		s[indexI] = n.String()
+	}
+	return strings.Join(s, " ")
+}
+
+func TestIPSet(t *testing.T) {
+	var s IPSet
+	if err := s.AddNet(MustParseNetwork("10.0.0.0/24")); nil != err {
+		t.Fatal(err)
+	}
+	if err := s.AddRange(MustParseIP("10.0.1.0"), MustParseIP("10.0.1.255")); nil != err {
+		t.Fatal(err)
+	}
+	if err := s.RemoveIP(MustParseIP("10.0.0.0")); nil != err {
+		t.Fatal(err)
+	}
+	if err := s.AddIP(MustParseIP("2001:db8::1")); nil != err {
+		t.Fatal(err)
+	}
+
+	if !s.Contains(MustParseIP("10.0.1.7")) || s.Contains(MustParseIP("10.0.0.0")) || s.Contains(MustParseIP("10.0.2.0")) {
+		t.Errorf("Contains() is wrong for %v", s.Ranges())
+	}
+	if !s.Contains(MustParseIP("2001:db8::1")) || s.Contains(MustParseIP("2001:db8::2")) {
+		t.Errorf("Contains() is wrong for IPv6")
+	}
+	if 0 != s.Size().Cmp(big.NewInt(512)) {
+		t.Errorf("Size() = %s, want 512", s.Size())
+	}
+	if 2 != len(s.Ranges()) {
+		t.Errorf("Ranges() = %v", s.Ranges())
+	}
+
+	want := "10.0.0.1/32 10.0.0.2/31 10.0.0.4/30 10.0.0.8/29 10.0.0.16/28 10.0.0.32/27 10.0.0.64/26 10.0.0.128/25 10.0.1.0/24 2001:db8::1/128"
+	if got := cidrStrings(s.CIDRs()); want != got {
+		t.Errorf("CIDRs() = %s, want %s", got, want)
+	}
+
+	if err := s.AddRange(MustParseIP("10.0.0.2"), MustParseIP("10.0.0.1")); nil == err {
+		t.Errorf("AddRange() of reversed range error = nil")
+	}
+	if err := s.AddRange(MustParseIP("10.0.0.1"), MustParseIP("::1")); nil == err {
+		t.Errorf("AddRange() of mixed families error = nil")
+	}
+}
+
+func TestIPSetAlgebra(t *testing.T) {
+	var a, b IPSet
+	a.AddRange(MustParseIP("10.0.0.0"), MustParseIP("10.0.0.99"))
+	a.AddNet(MustParseNetwork("::/0"))
+	b.AddRange(MustParseIP("10.0.0.50"), MustParseIP("10.0.0.149"))
+	b.AddNet(MustParseNetwork("2001:db8::/32"))
+
+	union := a.Union(&b)
+	if ranges := union.Ranges(); 2 != len(ranges) || "10.0.0.149" != ranges[0].Last.String() || "::" != ranges[1].First.String() {
+		t.Errorf("Union() = %v", ranges)
+	}
+	intersection := a.Intersection(&b)
+	if got := cidrStrings(intersection.CIDRs()); "10.0.0.50/31 10.0.0.52/30 10.0.0.56/29 10.0.0.64/27 10.0.0.96/30 2001:db8::/32" != got {
+		t.Errorf("Intersection() = %s", got)
+	}
+	difference := a.Difference(&b)
+	if 0 != difference.Intersection(&b).Size().Sign() {
+		t.Errorf("Difference() intersects the removed set")
+	}
+	if got := cidrStrings(b.Difference(&a).CIDRs()); "10.0.0.100/30 10.0.0.104/29 10.0.0.112/28 10.0.0.128/28 10.0.0.144/30 10.0.0.148/31" != got {
+		t.Errorf("Difference() = %s", got)
+	}
+
+	var all IPSet
+	all.AddNet(MustParseNetwork("::/0"))
+	all.AddIP(MustParseIP("::"))
+	if got := cidrStrings(all.CIDRs()); "::/0" != got {
+		t.Errorf("CIDRs() = %s", got)
+	}
+	if 129 != all.Size().BitLen() {
+		t.Errorf("Size() = %s", all.Size())
+	}
+}
+
+func TestIPSetIPs(t *testing.T) {
+	var s IPSet
+	s.AddRange(MustParseIP("10.0.0.254"), MustParseIP("10.0.1.1"))
+	s.AddIP(MustParseIP("::1"))
+
+	var got []string
+	for it := s.IPs(); it.Next(); {
+		got = append(got, it.IP().String())
+	}
+	if "10.0.0.254 10.0.0.255 10.0.1.0 10.0.1.1 ::1" != strings.Join(got, " ") {
+		t.Errorf("IPs() = %v", got)
+	}
+}
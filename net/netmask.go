@@ -0,0 +1,188 @@
+package net
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// MaskNotation is a textual form of a network with its mask.
+type MaskNotation int
+
+const (
+	// NotationCIDR is "10.0.0.0/24".
+	NotationCIDR MaskNotation = iota
+	// NotationNetmask is "10.0.0.0 255.255.255.0".
+	NotationNetmask
+	// NotationSlashNetmask is "10.0.0.0/255.255.255.0".
+	NotationSlashNetmask
+	// NotationWildcard is "10.0.0.0 0.0.0.255", a Cisco wildcard mask.
+	NotationWildcard
+	// NotationHex is "10.0.0.0/0xffffff00".
+	NotationHex
+)
+
+// ParseNetmask parses a mask as a dotted netmask such as "255.255.255.0",
+// as hex such as "0xffffff00" or "ffffff00", or as a prefix length. IPv6
+// masks are given in IPv6 notation, by 32 hex digits or by prefix length.
+// size is the length of the IPs of the network, net.IPv4len or
+// net.IPv6len. Non-contiguous masks are errors.
+func ParseNetmask(s string, size int) (net.IPMask, error) {
+	mask, err := parseMask(s, size)
+	if nil != err {
+		return nil, err
+	}
+	if _, bits := mask.Size(); 0 == bits {
+		return nil, fmt.Errorf("Net: non-contiguous mask %q", s)
+	}
+	return mask, nil
+}
+
+// ParseWildcardMask parses a Cisco wildcard mask such as "0.0.0.255",
+// which is the inverse of a netmask, in the forms of ParseNetmask except the
+// prefix length.
+func ParseWildcardMask(s string, size int) (net.IPMask, error) {
+	if isDecimal(s) {
+		return nil, fmt.Errorf("Net: wildcard mask %q must not be a prefix length", s)
+	}
+	mask, err := parseMask(s, size)
+	if nil != err {
+		return nil, err
+	}
+	for indexI := range mask {
+		mask[indexI] = ^mask[indexI]
+	}
+	if _, bits := mask.Size(); 0 == bits {
+		return nil, fmt.Errorf("Net: non-contiguous wildcard mask %q", s)
+	}
+	return mask, nil
+}
+
+func isDecimal(s string) bool {
+	if 0 == len(s) || 3 < len(s) {
+		return false
+	}
+	for _, c := range s {
+		if '0' > c || '9' < c {
+			return false
+		}
+	}
+	return true
+}
+
+func parseMask(s string, size int) (net.IPMask, error) {
+	if net.IPv4len != size && net.IPv6len != size {
+		return nil, fmt.Errorf("Net: invalid IP length %d of mask", size)
+	}
+	s = strings.TrimSpace(s)
+
+	switch {
+	case isDecimal(s):
+		ones, _ := strconv.Atoi(s)
+		if size*8 < ones {
+			return nil, fmt.Errorf("Net: prefix length %d is longer than %d", ones, size*8)
+		}
+		return net.CIDRMask(ones, size*8), nil
+	case strings.ContainsAny(s, ".:"):
+		ip := net.ParseIP(s)
+		if nil == ip {
+			return nil, &net.ParseError{Type: "netmask", Text: s}
+		}
+		if ip4 := ip.To4(); nil != ip4 && net.IPv4len == size {
+			ip = ip4
+		}
+		if len(ip) != size {
+			return nil, fmt.Errorf("Net: mask %q does not match the %d-byte IP", s, size)
+		}
+		return net.IPMask(ip), nil
+	default:
+		digits := strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+		if hex.EncodedLen(size) != len(digits) {
+			return nil, fmt.Errorf("Net: hex mask %q must have %d digits", s, hex.EncodedLen(size))
+		}
+		mask, err := hex.DecodeString(digits)
+		if nil != err {
+			return nil, &net.ParseError{Type: "netmask", Text: s}
+		}
+		return net.IPMask(mask), nil
+	}
+}
+
+// ParseNetmaskCIDR parses a network as "addr mask" or "addr/mask" with a
+// mask in a form of ParseNetmask, e.g. "10.0.0.1 255.255.255.0",
+// "10.0.0.1/255.255.255.0", "10.0.0.1/0xffffff00" or "10.0.0.1/24".
+// It returns the IP and the masked network like ParseCIDR.
+func ParseNetmaskCIDR(s string) (*IP, *IPNet, error) {
+	return parseMaskedCIDR(s, ParseNetmask)
+}
+
+// ParseWildcardCIDR parses a network as "addr wildcard" or "addr/wildcard"
+// with a Cisco wildcard mask, e.g. "10.0.0.1 0.0.0.255".
+// It returns the IP and the masked network like ParseCIDR.
+func ParseWildcardCIDR(s string) (*IP, *IPNet, error) {
+	return parseMaskedCIDR(s, ParseWildcardMask)
+}
+
+func parseMaskedCIDR(s string, parse func(s string, size int) (net.IPMask, error)) (*IP, *IPNet, error) {
+	s = strings.TrimSpace(s)
+	indexI := strings.IndexAny(s, "/ \t")
+	if 0 > indexI {
+		return nil, nil, fmt.Errorf("Net: %q has no mask", s)
+	}
+
+	ip := &IP{}
+	if err := ip.UnmarshalText([]byte(s[:indexI])); nil != err {
+		return nil, nil, err
+	}
+	mask, err := parse(s[indexI+1:], len(ip.IP))
+	if nil != err {
+		return nil, nil, err
+	}
+	return ip, &IPNet{net.IPNet{IP: ip.IP.Mask(mask), Mask: mask}}, nil
+}
+
+// Format returns the IPNet in a notation. IPv6 netmasks and wildcard masks
+// are written in IPv6 notation with all eight groups of four hex digits,
+// e.g. "ffff:ffff:0000:0000:0000:0000:0000:0000".
+func (i *IPNet) Format(notation MaskNotation) (string, error) {
+	ip, ones, err := i.prefix()
+	if nil != err {
+		return "", err
+	}
+	mask := net.CIDRMask(ones, len(ip)*8)
+
+	switch notation {
+	case NotationCIDR:
+		return ip.String() + "/" + strconv.Itoa(ones), nil
+	case NotationNetmask:
+		return ip.String() + " " + formatMask(mask), nil
+	case NotationSlashNetmask:
+		return ip.String() + "/" + formatMask(mask), nil
+	case NotationWildcard:
+		wildcard := make(net.IPMask, len(mask))
+		for indexI := range mask {
+			wildcard[indexI] = ^mask[indexI]
+		}
+		return ip.String() + " " + formatMask(wildcard), nil
+	case NotationHex:
+		return ip.String() + "/0x" + mask.String(), nil
+	default:
+		return "", fmt.Errorf("Net: unknown mask notation %d", notation)
+	}
+}
+
+// formatMask writes an IPv4 mask in dotted decimal and an IPv6 mask in full
+// hex groups. net.IP.String would write an IPv6 mask such as
+// ::ffff:ffff:ffff as an IPv4 address.
+func formatMask(mask net.IPMask) string {
+	if net.IPv6len != len(mask) {
+		return net.IP(mask).String()
+	}
+	groups := make([]string, 0, net.IPv6len/2)
+	for indexI := 0; indexI < net.IPv6len; indexI += 2 {
+		groups = append(groups, fmt.Sprintf("%02x%02x", mask[indexI], mask[indexI+1]))
+	}
+	return strings.Join(groups, ":")
+}
@@ -0,0 +1,108 @@
+package net
+
+import (
+	"testing"
+)
+
+func TestParseNetmaskCIDR(t *testing.T) {
+	tests := []struct {
+		s       string
+		ip      string
+		want    string
+		wantErr bool
+	}{
+		{"10.0.0.1 255.255.255.0", "10.0.0.1", "10.0.0.0/24", false},
+		{"10.0.0.1/255.255.255.0", "10.0.0.1", "10.0.0.0/24", false},
+		{"10.0.0.1/0xffffff00", "10.0.0.1", "10.0.0.0/24", false},
+		{"10.0.0.1 FFFFF000", "10.0.0.1", "10.0.0.0/20", false},
+		{"10.0.0.1/24", "10.0.0.1", "10.0.0.0/24", false},
+		{"10.0.0.1 0.0.0.0", "10.0.0.1", "0.0.0.0/0", false},
+		{"2001:db8::1 ffff:ffff::", "2001:db8::1", "2001:db8::/32", false},
+		{"2001:db8::1/ffffffff000000000000000000000000", "2001:db8::1", "2001:db8::/32", false},
+		{"10.0.0.1 255.0.255.0", "", "", true},
+		{"10.0.0.1/0xff00ff00", "", "", true},
+		{"10.0.0.1/33", "", "", true},
+		{"10.0.0.1 ffff::", "", "", true},
+		{"10.0.0.1", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			ip, n, err := ParseNetmaskCIDR(tt.s)
+			if (nil != err) != tt.wantErr {
+				t.Fatalf("ParseNetmaskCIDR() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.ip != ip.String() || tt.want != n.String() {
+				t.Errorf("ParseNetmaskCIDR() = %s, %s, want %s, %s", ip, n.String(), tt.ip, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWildcardCIDR(t *testing.T) {
+	_, n, err := ParseWildcardCIDR("10.0.0.0 0.0.0.255")
+	if nil != err || "10.0.0.0/24" != n.String() {
+		t.Errorf("ParseWildcardCIDR() = %v, %v", n, err)
+	}
+	if _, _, err = ParseWildcardCIDR("10.0.0.0 0.0.255.0"); nil == err {
+		t.Errorf("ParseWildcardCIDR() of non-contiguous mask error = nil")
+	}
+	if _, _, err = ParseWildcardCIDR("10.0.0.0/24"); nil == err {
+		t.Errorf("ParseWildcardCIDR() of prefix length error = nil")
+	}
+}
+
+func TestIPNetFormat(t *testing.T) {
+	n := MustParseNetwork("192.168.1.0/24")
+	tests := []struct {
+		notation MaskNotation
+		want     string
+	}{
+		{NotationCIDR, "192.168.1.0/24"},
+		{NotationNetmask, "192.168.1.0 255.255.255.0"},
+		{NotationSlashNetmask, "192.168.1.0/255.255.255.0"},
+		{NotationWildcard, "192.168.1.0 0.0.0.255"},
+		{NotationHex, "192.168.1.0/0xffffff00"},
+	}
+	for _, tt := range tests {
+		got, err := n.Format(tt.notation)
+		if nil != err || tt.want != got {
+			t.Errorf("Format(%d) = %s, %v, want %s", tt.notation, got, err, tt.want)
+		}
+		if NotationWildcard == tt.notation {
+			continue
+		}
+		if _, parsed, err := ParseNetmaskCIDR(got); nil != err || !parsed.Equal(n) {
+			t.Errorf("ParseNetmaskCIDR(%s) = %v, %v", got, parsed, err)
+		}
+	}
+
+	ipv6Tests := []struct {
+		network  string
+		notation MaskNotation
+		want     string
+	}{
+		{"2001:db8::/32", NotationWildcard, "2001:db8:: 0000:0000:ffff:ffff:ffff:ffff:ffff:ffff"},
+		{"2001:db8::/80", NotationWildcard, "2001:db8:: 0000:0000:0000:0000:0000:ffff:ffff:ffff"},
+		{"2001:db8::/32", NotationNetmask, "2001:db8:: ffff:ffff:0000:0000:0000:0000:0000:0000"},
+		{"2001:db8::/80", NotationSlashNetmask, "2001:db8::/ffff:ffff:ffff:ffff:ffff:0000:0000:0000"},
+		{"::/0", NotationNetmask, ":: 0000:0000:0000:0000:0000:0000:0000:0000"},
+	}
+	for _, tt := range ipv6Tests {
+		n := MustParseNetwork(tt.network)
+		got, err := n.Format(tt.notation)
+		if nil != err || tt.want != got {
+			t.Errorf("Format(%d) of %s = %s, %v, want %s", tt.notation, tt.network, got, err, tt.want)
+		}
+		parse := ParseNetmaskCIDR
+		if NotationWildcard == tt.notation {
+			parse = ParseWildcardCIDR
+		}
+		if _, parsed, err := parse(got); nil != err || !parsed.Equal(n) {
+			t.Errorf("parse of %s = %v, %v", got, parsed, err)
+		}
+	}
+}
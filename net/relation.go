@@ -0,0 +1,136 @@
+package net
+
+import (
+	"sort"
+)
+
+// Relation is the relation between two networks.
+type Relation int
+
+const (
+	// RelationIncomparable is the relation of networks of different
+	// families or with invalid masks.
+	RelationIncomparable Relation = iota
+	RelationDisjoint
+	// RelationAdjacent is the relation of disjoint networks without
+	// addresses between them.
+	RelationAdjacent
+	RelationEqual
+	// RelationSubset is the relation of a network to a network containing
+	// it.
+	RelationSubset
+	// RelationSuperset is the relation of a network to a network it
+	// contains.
+	RelationSuperset
+)
+
+func (r Relation) String() string {
+	switch r {
+	case RelationDisjoint:
+		return "disjoint"
+	case RelationAdjacent:
+		return "adjacent"
+	case RelationEqual:
+		return "equal"
+	case RelationSubset:
+		return "subset"
+	case RelationSuperset:
+		return "superset"
+	default:
+		return "incomparable"
+	}
+}
+
+// Relation returns the relation of the IPNet to other. Networks are
+// compared by their addresses, host bits of the IPs are ignored.
+func (i *IPNet) Relation(other IPNet) Relation {
+	size, r, err := netRangeOf(*i)
+	if nil != err {
+		return RelationIncomparable
+	}
+	otherSize, otherR, err := netRangeOf(other)
+	if nil != err || size != otherSize {
+		return RelationIncomparable
+	}
+
+	firstCmp, lastCmp := r.first.cmp(otherR.first), r.last.cmp(otherR.last)
+	switch {
+	case 0 == firstCmp && 0 == lastCmp:
+		return RelationEqual
+	case 0 <= firstCmp && 0 >= lastCmp:
+		return RelationSubset
+	case 0 >= firstCmp && 0 <= lastCmp:
+		return RelationSuperset
+	case follows(otherR.first, r.last) || follows(r.first, otherR.last):
+		return RelationAdjacent
+	default:
+		return RelationDisjoint
+	}
+}
+
+// follows reports whether next is the address right after last. The
+// maximum IPv6 address wraps around to zero, which does not follow it.
+func follows(next uint128, last uint128) bool {
+	n := last.addOne()
+	return !n.isZero() && n == next
+}
+
+// Equal reports whether the IPNet and other have the same addresses.
+func (i *IPNet) Equal(other IPNet) bool {
+	return RelationEqual == i.Relation(other)
+}
+
+// Overlaps reports whether the IPNet and other have addresses in common.
+// Networks of different families never overlap.
+func (i *IPNet) Overlaps(other IPNet) bool {
+	switch i.Relation(other) {
+	case RelationEqual, RelationSubset, RelationSuperset:
+		return true
+	}
+	return false
+}
+
+// Adjacent reports whether other starts right after the IPNet ends or ends
+// right before it starts.
+func (i *IPNet) Adjacent(other IPNet) bool {
+	return RelationAdjacent == i.Relation(other)
+}
+
+// Compare returns -1, 0 or 1 if the IPNet sorts before, with or after
+// other. Invalid networks come first, then IPv4 before IPv6 networks,
+// ordered by network address and then by prefix length, so that a network
+// comes before its subnets.
+func (i *IPNet) Compare(other IPNet) int {
+	size, r, err := netRangeOf(*i)
+	otherSize, otherR, otherErr := netRangeOf(other)
+	switch {
+	case nil != err && nil != otherErr:
+		return 0
+	case nil != err:
+		return -1
+	case nil != otherErr:
+		return 1
+	case size != otherSize:
+		if size < otherSize {
+			return -1
+		}
+		return 1
+	}
+	if c := r.first.cmp(otherR.first); 0 != c {
+		return c
+	}
+	// The larger network, which has the greater last address, comes first.
+	return -r.last.cmp(otherR.last)
+}
+
+// IPNets is a slice of networks sorted by IPNet.Compare.
+type IPNets []IPNet
+
+func (s IPNets) Len() int           { return len(s) }
+func (s IPNets) Less(i, j int) bool { return -1 == s[i].Compare(s[j]) }
+func (s IPNets) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// SortIPNets sorts nets in increasing order of IPNet.Compare.
+func SortIPNets(nets []IPNet) {
+	sort.Sort(IPNets(nets))
+}
@@ -0,0 +1,81 @@
+package net
+
+import (
+	"net"
+	"testing"
+)
+
+func TestIPNetRelation(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		want Relation
+	}{
+		{"10.0.0.0/24", "10.0.0.0/24", RelationEqual},
+		{"10.0.0.7/24", "10.0.0.0/24", RelationEqual},
+		{"10.0.1.0/24", "10.0.0.0/16", RelationSubset},
+		{"10.0.0.0/16", "10.0.1.0/24", RelationSuperset},
+		{"10.0.0.0/24", "10.0.1.0/24", RelationAdjacent},
+		{"10.0.1.0/24", "10.0.0.0/24", RelationAdjacent},
+		{"10.0.0.0/24", "10.0.2.0/24", RelationDisjoint},
+		{"10.0.0.0/8", "::/0", RelationIncomparable},
+		{"2001:db8::/33", "2001:db8:8000::/33", RelationAdjacent},
+		{"2001:db8::/32", "::/0", RelationSubset},
+		{"ffff::/16", "::/16", RelationDisjoint},
+		{"::/16", "ffff::/16", RelationDisjoint},
+		{"255.255.0.0/16", "0.0.0.0/16", RelationDisjoint},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			a := MustParseCIDR(tt.a)
+			b := MustParseCIDR(tt.b)
+			if got := a.Relation(b); got != tt.want {
+				t.Errorf("Relation() = %s, want %s", got, tt.want)
+			}
+			overlaps := RelationEqual == tt.want || RelationSubset == tt.want || RelationSuperset == tt.want
+			if got := a.Overlaps(b); got != overlaps {
+				t.Errorf("Overlaps() = %v, want %v", got, overlaps)
+			}
+			if got := a.IsNetOverlap(b.IPNet); got != overlaps {
+				t.Errorf("IsNetOverlap() = %v, want %v", got, overlaps)
+			}
+			if got := a.Equal(b); got != (RelationEqual == tt.want) {
+				t.Errorf("Equal() = %v", got)
+			}
+			if got := a.Adjacent(b); got != (RelationAdjacent == tt.want) {
+				t.Errorf("Adjacent() = %v", got)
+			}
+		})
+	}
+}
+
+func TestIPNetCompare(t *testing.T) {
+	nets := []IPNet{
+		MustParseNetwork("::/0"),
+		MustParseNetwork("10.0.1.0/24"),
+		MustParseNetwork("10.0.0.0/24"),
+		MustParseNetwork("10.0.0.0/16"),
+		{},
+		MustParseNetwork("9.0.0.0/8"),
+	}
+	SortIPNets(nets)
+	want := []string{"<nil>", "9.0.0.0/8", "10.0.0.0/16", "10.0.0.0/24", "10.0.1.0/24", "::/0"}
+	for indexI, n := range nets {
+		if want[indexI] != n.String() {
+			t.Errorf("sorted[%d] = %s, want %s", indexI, n.String(), want[indexI])
+		}
+	}
+}
+
+func TestIsNetOverlapNonCanonical(t *testing.T) {
+	// Masks with holes are not comparable by Relation, but IsNetOverlap
+	// still compares them by Contains.
+	n := IPNet{net.IPNet{IP: net.IPv4(10, 0, 0, 0).To4(), Mask: net.IPv4Mask(255, 0, 255, 0)}}
+	if n.Overlaps(MustParseNetwork("10.0.0.0/8")) {
+		t.Errorf("Overlaps() of non-canonical mask = true")
+	}
+	if !n.IsNetOverlap(MustParseNetwork("10.0.0.0/8").IPNet) {
+		t.Errorf("IsNetOverlap() of non-canonical mask = false")
+	}
+}
@@ -0,0 +1,223 @@
+package net
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+const (
+	ipv4ReverseSuffix = "in-addr.arpa."
+	ipv6ReverseSuffix = "ip6.arpa."
+	hexDigits         = "0123456789abcdef"
+)
+
+// ReverseName returns the fully qualified name of the PTR record of the IP,
+// e.g. "1.2.0.192.in-addr.arpa." or "1.0.0.0. ... .8.b.d.0.1.0.0.2.ip6.arpa.".
+func (i IP) ReverseName() (string, error) {
+	ip := i.normalized()
+	if nil == ip {
+		return "", fmt.Errorf("Net: invalid IP %v", i.IP)
+	}
+	return reverseLabels(ip, len(ip)*8), nil
+}
+
+// reverseLabels returns the reverse name of the first ones bits of ip,
+// which must be a multiple of 8 for IPv4 and of 4 for IPv6.
+func reverseLabels(ip net.IP, ones int) string {
+	var b strings.Builder
+	if net.IPv4len == len(ip) {
+		for indexI := ones/8 - 1; 0 <= indexI; indexI-- {
+			b.WriteString(strconv.Itoa(int(ip[indexI])))
+			b.WriteByte('.')
+		}
+		b.WriteString(ipv4ReverseSuffix)
+		return b.String()
+	}
+	for indexI := ones/4 - 1; 0 <= indexI; indexI-- {
+		nibble := ip[indexI/2] >> 4
+		if 1 == indexI%2 {
+			nibble = ip[indexI/2] & 0x0f
+		}
+		b.WriteByte(hexDigits[nibble])
+		b.WriteByte('.')
+	}
+	b.WriteString(ipv6ReverseSuffix)
+	return b.String()
+}
+
+// ReverseZones returns the names of the reverse zones which delegate
+// exactly the addresses of the IPNet. Prefixes which do not end at a label
+// boundary, an octet for IPv4 and a nibble for IPv6, are split into the
+// zones of the next boundary, e.g. 10.0.0.0/23 into 0.0.10.in-addr.arpa.
+// and 1.0.10.in-addr.arpa. IPv4 prefixes from /25 to /31 are named as in
+// RFC 2317 classless delegation, e.g. "64/26.2.0.192.in-addr.arpa.".
+func (i *IPNet) ReverseZones() ([]string, error) {
+	ip, ones, err := i.prefix()
+	if nil != err {
+		return nil, err
+	}
+	ip = ip.Mask(net.CIDRMask(ones, len(ip)*8))
+
+	labelBits := 4
+	if net.IPv4len == len(ip) {
+		labelBits = 8
+		if 24 < ones && 32 > ones {
+			return []string{strconv.Itoa(int(ip[3])) + "/" + strconv.Itoa(ones) + "." + reverseLabels(ip, 24)}, nil
+		}
+	}
+
+	zoneOnes := (ones + labelBits - 1) / labelBits * labelBits
+	zone := maskedIPNet(ip, ones)
+	it, err := zone.Subnets(zoneOnes)
+	if nil != err {
+		return nil, err
+	}
+	var zones []string
+	for it.Next() {
+		zones = append(zones, reverseLabels(it.Subnet().IP, zoneOnes))
+	}
+	return zones, nil
+}
+
+// ParseReverseName parses the name of a PTR record, with or without the
+// final dot, into its IP.
+func ParseReverseName(name string) (*IP, error) {
+	n, err := ParseReverseZone(name)
+	if nil != err {
+		return nil, err
+	}
+	if ones, bits := n.Mask.Size(); ones != bits {
+		return nil, fmt.Errorf("Net: %q is not the name of an address", name)
+	}
+	return &IP{IP: n.IP}, nil
+}
+
+// ParseReverseZone parses a reverse name, with or without the final dot,
+// into the network it covers. It accepts the names of ReverseName and of
+// ReverseZones. RFC 2317 labels are accepted with a prefix length as in
+// "64/26" or with the range of the last octet as in "64-127".
+func ParseReverseZone(name string) (*IPNet, error) {
+	lower := strings.ToLower(strings.TrimSpace(name))
+	if !strings.HasSuffix(lower, ".") {
+		lower += "."
+	}
+
+	var labels []string
+	var size int
+	switch {
+	case strings.HasSuffix(lower, ipv4ReverseSuffix):
+		labels, size = reverseNameLabels(strings.TrimSuffix(lower, ipv4ReverseSuffix)), net.IPv4len
+	case strings.HasSuffix(lower, ipv6ReverseSuffix):
+		labels, size = reverseNameLabels(strings.TrimSuffix(lower, ipv6ReverseSuffix)), net.IPv6len
+	default:
+		return nil, fmt.Errorf("Net: %q is not a reverse name", name)
+	}
+
+	ip := make(net.IP, size)
+	if net.IPv4len == size {
+		return parseIPv4ReverseLabels(name, labels, ip)
+	}
+
+	if net.IPv6len*2 < len(labels) {
+		return nil, fmt.Errorf("Net: %q has too many labels", name)
+	}
+	for indexI, label := range labels {
+		if 1 != len(label) || 0 > strings.IndexByte(hexDigits, label[0]) {
+			return nil, fmt.Errorf("Net: invalid label %q in %q", label, name)
+		}
+		nibble := strings.IndexByte(hexDigits, label[0])
+		position := len(labels) - 1 - indexI
+		if 0 == position%2 {
+			ip[position/2] |= byte(nibble) << 4
+		} else {
+			ip[position/2] |= byte(nibble)
+		}
+	}
+	return &IPNet{net.IPNet{IP: ip, Mask: net.CIDRMask(len(labels)*4, net.IPv6len*8)}}, nil
+}
+
+// reverseNameLabels returns the labels of the part of a name before the
+// suffix, which ends with a dot.
+func reverseNameLabels(s string) []string {
+	s = strings.TrimSuffix(s, ".")
+	if "" == s {
+		return nil
+	}
+	return strings.Split(s, ".")
+}
+
+func parseIPv4ReverseLabels(name string, labels []string, ip net.IP) (*IPNet, error) {
+	if net.IPv4len < len(labels) {
+		return nil, fmt.Errorf("Net: %q has too many labels", name)
+	}
+
+	ones := len(labels) * 8
+	for indexI, label := range labels {
+		position := len(labels) - 1 - indexI
+		// RFC 2317 label of a prefix longer than /24.
+		if indexJ := strings.IndexAny(label, "/-"); 0 <= indexJ && 0 == indexI && 3 == position {
+			prefixLen, err := parseRFC2317Label(label, indexJ)
+			if nil != err {
+				return nil, fmt.Errorf("Net: invalid RFC 2317 label %q in %q", label, name)
+			}
+			label, ones = label[:indexJ], prefixLen
+		}
+		octet, err := parseOctet(label)
+		if nil != err {
+			return nil, fmt.Errorf("Net: invalid label %q in %q", label, name)
+		}
+		ip[position] = octet
+	}
+
+	mask := net.CIDRMask(ones, net.IPv4len*8)
+	if !ip.Mask(mask).Equal(ip) {
+		return nil, fmt.Errorf("Net: %q has host bits set", name)
+	}
+	return &IPNet{net.IPNet{IP: ip, Mask: mask}}, nil
+}
+
+// parseRFC2317Label returns the prefix length of an RFC 2317 label whose
+// separator is at indexJ, "first/prefixlen" or "first-last".
+func parseRFC2317Label(label string, indexJ int) (int, error) {
+	if '/' == label[indexJ] {
+		prefixLen, err := strconv.Atoi(label[indexJ+1:])
+		if nil != err || 24 >= prefixLen || 32 < prefixLen {
+			return 0, fmt.Errorf("invalid prefix length")
+		}
+		return prefixLen, nil
+	}
+
+	first, err := parseOctet(label[:indexJ])
+	if nil != err {
+		return 0, err
+	}
+	last, err := parseOctet(label[indexJ+1:])
+	if nil != err {
+		return 0, err
+	}
+	size := int(last) - int(first) + 1
+	// The range must be a block of 2 to 128 addresses.
+	if 2 > size || 128 < size || 0 != size&(size-1) {
+		return 0, fmt.Errorf("invalid range")
+	}
+	prefixLen := 32
+	for ; 1 < size; size >>= 1 {
+		prefixLen--
+	}
+	return prefixLen, nil
+}
+
+// parseOctet parses a decimal octet without leading zeros, as in canonical
+// reverse names.
+func parseOctet(s string) (byte, error) {
+	if 1 < len(s) && '0' == s[0] {
+		return 0, fmt.Errorf("leading zero in %q", s)
+	}
+	octet, err := strconv.ParseUint(s, 10, 8)
+	if nil != err {
+		return 0, err
+	}
+	return byte(octet), nil
+}
@@ -0,0 +1,104 @@
+package net
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReverseName(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{"192.0.2.1", "1.2.0.192.in-addr.arpa."},
+		{"2001:db8::567:89ab", "b.a.9.8.7.6.5.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			name, err := MustParseIP(tt.ip).ReverseName()
+			if nil != err || tt.want != name {
+				t.Fatalf("ReverseName() = %s, %v, want %s", name, err, tt.want)
+			}
+			ip, err := ParseReverseName(strings.ToUpper(strings.TrimSuffix(name, ".")))
+			if nil != err || tt.ip != ip.String() {
+				t.Errorf("ParseReverseName() = %v, %v", ip, err)
+			}
+		})
+	}
+
+	if _, err := ParseReverseName("2.0.192.in-addr.arpa."); nil == err {
+		t.Errorf("ParseReverseName() of zone error = nil")
+	}
+	if _, err := ParseReverseName("1.2.0.192.example.com."); nil == err {
+		t.Errorf("ParseReverseName() of other name error = nil")
+	}
+}
+
+func TestReverseZones(t *testing.T) {
+	tests := []struct {
+		n    string
+		want string
+	}{
+		{"10.0.0.0/8", "10.in-addr.arpa."},
+		{"10.0.0.0/23", "0.0.10.in-addr.arpa. 1.0.10.in-addr.arpa."},
+		{"192.0.2.64/26", "64/26.2.0.192.in-addr.arpa."},
+		{"192.0.2.5/32", "5.2.0.192.in-addr.arpa."},
+		{"0.0.0.0/0", "in-addr.arpa."},
+		{"2001:db8::/32", "8.b.d.0.1.0.0.2.ip6.arpa."},
+		{"2001:db8::/31", "8.b.d.0.1.0.0.2.ip6.arpa. 9.b.d.0.1.0.0.2.ip6.arpa."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.n, func(t *testing.T) {
+			n := MustParseNetwork(tt.n)
+			zones, err := n.ReverseZones()
+			if nil != err || tt.want != strings.Join(zones, " ") {
+				t.Fatalf("ReverseZones() = %v, %v, want %s", zones, err, tt.want)
+			}
+			if 1 != len(zones) {
+				return
+			}
+			parsed, err := ParseReverseZone(zones[0])
+			if nil != err || !parsed.Equal(n) {
+				t.Errorf("ParseReverseZone() = %v, %v", parsed, err)
+			}
+		})
+	}
+
+	rangeTests := []struct {
+		name string
+		want string
+	}{
+		{"0-127.2.0.192.in-addr.arpa", "192.0.2.0/25"},
+		{"128-255.2.0.192.in-addr.arpa", "192.0.2.128/25"},
+		{"64-127.2.0.192.in-addr.arpa.", "192.0.2.64/26"},
+		{"4-5.2.0.192.in-addr.arpa", "192.0.2.4/31"},
+	}
+	for _, tt := range rangeTests {
+		if n, err := ParseReverseZone(tt.name); nil != err || tt.want != n.String() {
+			t.Errorf("ParseReverseZone(%q) = %v, %v, want %s", tt.name, n, err, tt.want)
+		}
+	}
+	invalidTests := []string{
+		"010.0.0.10.in-addr.arpa",
+		"10.00.0.10.in-addr.arpa",
+		"0-100.2.0.192.in-addr.arpa",
+		"64-128.2.0.192.in-addr.arpa",
+		"0-255.2.0.192.in-addr.arpa",
+		"5-5.2.0.192.in-addr.arpa",
+		"00-127.2.0.192.in-addr.arpa",
+		"64/24.2.0.192.in-addr.arpa",
+	}
+	for _, name := range invalidTests {
+		if n, err := ParseReverseZone(name); nil == err {
+			t.Errorf("ParseReverseZone(%q) = %v, want error", name, n)
+		}
+	}
+	if _, err := ParseReverseZone("1..8.b.d.0.1.0.0.2.ip6.arpa."); nil == err {
+		t.Errorf("ParseReverseZone() with empty label error = nil")
+	}
+	if _, err := ParseReverseZone("65/26.2.0.192.in-addr.arpa."); nil == err {
+		t.Errorf("ParseReverseZone() with host bits error = nil")
+	}
+}
@@ -0,0 +1,353 @@
+package net
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Class is a set of flags describing the special purposes of an address.
+type Class uint32
+
+const (
+	// ClassSpecialPurpose is set for every address in the special-purpose
+	// registries.
+	ClassSpecialPurpose Class = 1 << iota
+	ClassUnspecified
+	ClassThisNetwork
+	ClassLoopback
+	ClassPrivate
+	ClassSharedAddress
+	ClassLinkLocal
+	ClassUniqueLocal
+	ClassDocumentation
+	ClassBenchmarking
+	ClassMulticast
+	ClassBroadcast
+	ClassReserved
+	ClassProtocolAssignment
+	ClassAnycast
+	ClassTranslation
+	ClassTransition
+	ClassDiscardOnly
+	ClassDeprecated
+)
+
+var classNames = []struct {
+	class Class
+	name  string
+}{
+	{ClassSpecialPurpose, "special-purpose"},
+	{ClassUnspecified, "unspecified"},
+	{ClassThisNetwork, "this-network"},
+	{ClassLoopback, "loopback"},
+	{ClassPrivate, "private"},
+	{ClassSharedAddress, "shared-address"},
+	{ClassLinkLocal, "link-local"},
+	{ClassUniqueLocal, "unique-local"},
+	{ClassDocumentation, "documentation"},
+	{ClassBenchmarking, "benchmarking"},
+	{ClassMulticast, "multicast"},
+	{ClassBroadcast, "broadcast"},
+	{ClassReserved, "reserved"},
+	{ClassProtocolAssignment, "protocol-assignment"},
+	{ClassAnycast, "anycast"},
+	{ClassTranslation, "translation"},
+	{ClassTransition, "transition"},
+	{ClassDiscardOnly, "discard-only"},
+	{ClassDeprecated, "deprecated"},
+}
+
+// Has reports whether c has any of the flags of flags.
+func (c Class) Has(flags Class) bool {
+	return 0 != c&flags
+}
+
+// String returns the names of the flags of c joined by "|".
+func (c Class) String() string {
+	names := make([]string, 0)
+	for _, cn := range classNames {
+		if c.Has(cn.class) {
+			names = append(names, cn.name)
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+// Attribute is a boolean attribute of a registry entry, which the registry
+// may leave unspecified.
+type Attribute int8
+
+const (
+	AttributeUnspecified Attribute = iota
+	AttributeFalse
+	AttributeTrue
+)
+
+func (a Attribute) String() string {
+	switch a {
+	case AttributeFalse:
+		return "False"
+	case AttributeTrue:
+		return "True"
+	default:
+		return "N/A"
+	}
+}
+
+// SpecialPurposeEntry is an address block of the IANA IPv4 and IPv6
+// special-purpose address registries, or a multicast address block.
+type SpecialPurposeEntry struct {
+	Network            IPNet
+	Name               string
+	RFC                string
+	Allocated          string // allocation date as "YYYY-MM"
+	Terminated         string // termination date as "YYYY-MM", or ""
+	Source             Attribute
+	Destination        Attribute
+	Forwardable        Attribute
+	GloballyReachable  Attribute
+	ReservedByProtocol Attribute
+	Class              Class
+}
+
+//go:generate go run gen_special.go
+
+// specialClasses are the classes of the address blocks of the registries.
+var specialClasses = map[string]Class{
+	"0.0.0.0/8":          ClassThisNetwork,
+	"0.0.0.0/32":         ClassUnspecified,
+	"10.0.0.0/8":         ClassPrivate,
+	"100.64.0.0/10":      ClassSharedAddress,
+	"127.0.0.0/8":        ClassLoopback,
+	"169.254.0.0/16":     ClassLinkLocal,
+	"172.16.0.0/12":      ClassPrivate,
+	"192.0.0.0/24":       ClassProtocolAssignment,
+	"192.0.0.0/29":       ClassTransition,
+	"192.0.0.9/32":       ClassAnycast,
+	"192.0.0.10/32":      ClassAnycast,
+	"192.0.0.170/32":     ClassTranslation,
+	"192.0.0.171/32":     ClassTranslation,
+	"192.0.2.0/24":       ClassDocumentation,
+	"192.31.196.0/24":    ClassAnycast,
+	"192.88.99.0/24":     ClassTransition,
+	"192.88.99.2/32":     ClassAnycast | ClassTransition,
+	"192.168.0.0/16":     ClassPrivate,
+	"192.175.48.0/24":    ClassAnycast,
+	"198.18.0.0/15":      ClassBenchmarking,
+	"198.51.100.0/24":    ClassDocumentation,
+	"203.0.113.0/24":     ClassDocumentation,
+	"240.0.0.0/4":        ClassReserved,
+	"255.255.255.255/32": ClassBroadcast,
+
+	"::1/128":           ClassLoopback,
+	"::/128":            ClassUnspecified,
+	"::ffff:0:0/96":     ClassTransition,
+	"64:ff9b::/96":      ClassTranslation,
+	"64:ff9b:1::/48":    ClassTranslation,
+	"100::/64":          ClassDiscardOnly,
+	"2001::/23":         ClassProtocolAssignment,
+	"2001::/32":         ClassTransition,
+	"2001:1::1/128":     ClassAnycast,
+	"2001:1::2/128":     ClassAnycast,
+	"2001:1::3/128":     ClassAnycast,
+	"2001:2::/48":       ClassBenchmarking,
+	"2001:4:112::/48":   ClassAnycast,
+	"2001:db8::/32":     ClassDocumentation,
+	"2002::/16":         ClassTransition,
+	"2620:4f:8000::/48": ClassAnycast,
+	"3fff::/20":         ClassDocumentation,
+	"fc00::/7":          ClassUniqueLocal | ClassPrivate,
+	"fe80::/10":         ClassLinkLocal,
+}
+
+// multicastEntries are the multicast address spaces, which are not part of
+// the special-purpose registries.
+var multicastEntries = []SpecialPurposeEntry{
+	{
+		Network:   MustParseNetwork("224.0.0.0/4"),
+		Name:      "Multicast",
+		RFC:       "[RFC5771]",
+		Allocated: "1989-08",
+		Class:     ClassMulticast,
+	},
+	{
+		Network:   MustParseNetwork("ff00::/8"),
+		Name:      "Multicast",
+		RFC:       "[RFC4291]",
+		Allocated: "2006-02",
+		Class:     ClassMulticast,
+	},
+}
+
+var (
+	specialOnce    sync.Once
+	specialEntries []SpecialPurposeEntry
+)
+
+// specialPurposeEntries returns the parsed registries. The generated
+// registries are part of the source, so a parse error is a bug.
+func specialPurposeEntries() []SpecialPurposeEntry {
+	specialOnce.Do(func() {
+		for _, registry := range []string{ipv4SpecialRegistry, ipv6SpecialRegistry} {
+			entries, err := parseSpecialRegistry(registry)
+			if nil != err {
+				panic(err)
+			}
+			specialEntries = append(specialEntries, entries...)
+		}
+		specialEntries = append(specialEntries, multicastEntries...)
+	})
+	return specialEntries
+}
+
+// parseSpecialRegistry parses a special-purpose registry in the CSV format
+// of IANA.
+func parseSpecialRegistry(data string) ([]SpecialPurposeEntry, error) {
+	records, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if nil != err {
+		return nil, err
+	}
+	if 1 > len(records) {
+		return nil, fmt.Errorf("Net: empty special-purpose registry")
+	}
+
+	entries := make([]SpecialPurposeEntry, 0, len(records)-1)
+	for _, record := range records[1:] {
+		if 10 != len(record) {
+			return nil, fmt.Errorf("Net: special-purpose registry record %q has %d fields", record, len(record))
+		}
+		for _, block := range strings.Split(record[0], ",") {
+			block = stripFootnotes(block)
+			_, n, err := ParseCIDR(block)
+			if nil != err {
+				return nil, err
+			}
+			entry := SpecialPurposeEntry{
+				Network:            *n,
+				Name:               strings.Trim(stripFootnotes(record[1]), `"`),
+				RFC:                strings.Join(strings.Fields(record[2]), " "),
+				Allocated:          stripFootnotes(record[3]),
+				Terminated:         stripFootnotes(record[4]),
+				Source:             parseAttribute(record[5]),
+				Destination:        parseAttribute(record[6]),
+				Forwardable:        parseAttribute(record[7]),
+				GloballyReachable:  parseAttribute(record[8]),
+				ReservedByProtocol: parseAttribute(record[9]),
+				Class:              ClassSpecialPurpose | specialClasses[block],
+			}
+			if "N/A" == entry.Terminated {
+				entry.Terminated = ""
+			}
+			if "" != entry.Terminated {
+				entry.Class |= ClassDeprecated
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// stripFootnotes removes footnote references as in "False [1]".
+func stripFootnotes(s string) string {
+	if indexI := strings.Index(s, " ["); 0 <= indexI {
+		s = s[:indexI]
+	}
+	return strings.TrimSpace(s)
+}
+
+func parseAttribute(s string) Attribute {
+	switch stripFootnotes(s) {
+	case "True":
+		return AttributeTrue
+	case "False":
+		return AttributeFalse
+	default:
+		return AttributeUnspecified
+	}
+}
+
+// SpecialPurposeRegistry returns the entries of the IANA IPv4 and IPv6
+// special-purpose address registries followed by the multicast address
+// spaces.
+func SpecialPurposeRegistry() []SpecialPurposeEntry {
+	entries := specialPurposeEntries()
+	registry := make([]SpecialPurposeEntry, len(entries))
+	copy(registry, entries)
+	return registry
+}
+
+// LookupSpecialPurpose returns the entries whose address block contains the
+// IP, from the least to the most specific block.
+func LookupSpecialPurpose(ip IP) []SpecialPurposeEntry {
+	addr := ip.normalized()
+	if nil == addr {
+		return nil
+	}
+
+	var matches []SpecialPurposeEntry
+	for _, entry := range specialPurposeEntries() {
+		if len(addr) == len(entry.Network.IP) && entry.Network.Contains(addr) {
+			matches = append(matches, entry)
+		}
+	}
+	// Blocks are nested or disjoint, so the prefix length orders them.
+	for indexI := 1; indexI < len(matches); indexI++ {
+		for indexJ := indexI; 0 < indexJ && prefixLen(matches[indexJ]) < prefixLen(matches[indexJ-1]); indexJ-- {
+			matches[indexJ], matches[indexJ-1] = matches[indexJ-1], matches[indexJ]
+		}
+	}
+	return matches
+}
+
+func prefixLen(entry SpecialPurposeEntry) int {
+	ones, _ := entry.Network.Mask.Size()
+	return ones
+}
+
+// Class returns the flags of all address blocks containing the IP, 0 for
+// global unicast and invalid addresses.
+func (i IP) Class() Class {
+	var class Class
+	for _, entry := range LookupSpecialPurpose(i) {
+		class |= entry.Class
+	}
+	return class
+}
+
+// IsBogon reports whether the IP must not appear on the public Internet,
+// that is whether it is multicast or the most specific address block
+// containing it is not globally reachable. Invalid IPs are bogons.
+func (i IP) IsBogon() bool {
+	if nil == i.normalized() {
+		return true
+	}
+	entries := LookupSpecialPurpose(i)
+	for indexI := len(entries) - 1; 0 <= indexI; indexI-- {
+		if entries[indexI].Class.Has(ClassMulticast) {
+			return true
+		}
+		switch entries[indexI].GloballyReachable {
+		case AttributeFalse:
+			return true
+		case AttributeTrue:
+			return false
+		}
+		// Reachability of Teredo and 6to4 depends on the embedded IPv4
+		// address, blocks without attributes are deprecated.
+		if "" == entries[indexI].Terminated {
+			return false
+		}
+	}
+	return false
+}
+
+// IsGlobalUnicast reports whether the IP is a unicast address which is
+// reachable on the public Internet according to the special-purpose
+// registries. Unlike net.IP.IsGlobalUnicast it is false for private,
+// shared, documentation and other addresses that are not globally
+// reachable.
+func (i IP) IsGlobalUnicast() bool {
+	return !i.IsBogon() && !i.Class().Has(ClassBroadcast|ClassMulticast)
+}
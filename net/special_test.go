@@ -0,0 +1,87 @@
+package net
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func TestIPClass(t *testing.T) {
+	tests := []struct {
+		ip     string
+		class  Class
+		bogon  bool
+		global bool
+	}{
+		{"8.8.8.8", 0, false, true},
+		{"0.0.0.0", ClassSpecialPurpose | ClassThisNetwork | ClassUnspecified, true, false},
+		{"127.0.0.1", ClassSpecialPurpose | ClassLoopback, true, false},
+		{"10.1.2.3", ClassSpecialPurpose | ClassPrivate, true, false},
+		{"100.64.0.1", ClassSpecialPurpose | ClassSharedAddress, true, false},
+		{"169.254.1.1", ClassSpecialPurpose | ClassLinkLocal, true, false},
+		{"192.0.0.9", ClassSpecialPurpose | ClassProtocolAssignment | ClassAnycast, false, true},
+		{"192.0.2.1", ClassSpecialPurpose | ClassDocumentation, true, false},
+		{"198.19.0.1", ClassSpecialPurpose | ClassBenchmarking, true, false},
+		{"224.0.0.1", ClassMulticast, true, false},
+		{"250.0.0.1", ClassSpecialPurpose | ClassReserved, true, false},
+		{"255.255.255.255", ClassSpecialPurpose | ClassReserved | ClassBroadcast, true, false},
+		{"::ffff:10.0.0.1", ClassSpecialPurpose | ClassPrivate, true, false},
+		{"2606:4700::1111", 0, false, true},
+		{"::1", ClassSpecialPurpose | ClassLoopback, true, false},
+		{"fe80::1", ClassSpecialPurpose | ClassLinkLocal, true, false},
+		{"fd00::1", ClassSpecialPurpose | ClassUniqueLocal | ClassPrivate, true, false},
+		{"2001:db8::1", ClassSpecialPurpose | ClassDocumentation, true, false},
+		{"2001:0:4136:e378::1", ClassSpecialPurpose | ClassProtocolAssignment | ClassTransition, false, true},
+		{"2001:10::1", ClassSpecialPurpose | ClassProtocolAssignment | ClassDeprecated, true, false},
+		{"ff02::1", ClassMulticast, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			ip := MustParseIP(tt.ip)
+			if got := ip.Class(); got != tt.class {
+				t.Errorf("Class() = %s, want %s", got, tt.class)
+			}
+			if got := ip.IsBogon(); got != tt.bogon {
+				t.Errorf("IsBogon() = %v, want %v", got, tt.bogon)
+			}
+			if got := ip.IsGlobalUnicast(); got != tt.global {
+				t.Errorf("IsGlobalUnicast() = %v, want %v", got, tt.global)
+			}
+		})
+	}
+}
+
+func TestSpecialPurposeRegistry(t *testing.T) {
+	registry := SpecialPurposeRegistry()
+	if 40 > len(registry) {
+		t.Fatalf("SpecialPurposeRegistry() has %d entries", len(registry))
+	}
+	for _, entry := range registry {
+		if "" == entry.Name || "" == entry.Allocated {
+			t.Errorf("entry %+v is incomplete", entry)
+		}
+	}
+
+	entries := LookupSpecialPurpose(MustParseIP("192.0.0.170"))
+	if 2 != len(entries) || "NAT64/DNS64 Discovery" != entries[1].Name || "[RFC8880][RFC7050], Section 2.2" != entries[1].RFC {
+		t.Errorf("LookupSpecialPurpose() = %+v", entries)
+	}
+	if AttributeTrue != entries[1].ReservedByProtocol {
+		t.Errorf("ReservedByProtocol = %s", entries[1].ReservedByProtocol)
+	}
+}
+
+func TestSpecialRegistryGenerated(t *testing.T) {
+	for file, registry := range map[string]string{
+		"iana/iana-ipv4-special-registry.csv": ipv4SpecialRegistry,
+		"iana/iana-ipv6-special-registry.csv": ipv6SpecialRegistry,
+	} {
+		data, err := ioutil.ReadFile(file)
+		if nil != err {
+			t.Fatal(err)
+		}
+		if string(data) != registry {
+			t.Errorf("special_registry.go is out of date with %s, run go generate", file)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package net
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// scanText returns the textual form of a database value, ok is false for
+// NULL. A non-empty []byte of white space only is returned as is, so that
+// it fails to parse as text and is tried as raw bytes instead of NULL.
+func scanText(src interface{}) (text string, ok bool, err error) {
+	switch v := src.(type) {
+	case nil:
+		return "", false, nil
+	case string:
+		return strings.TrimSpace(v), true, nil
+	case []byte:
+		if text = strings.TrimSpace(string(v)); "" == text {
+			return string(v), true, nil
+		}
+		return text, true, nil
+	default:
+		return "", false, fmt.Errorf("Net: can not scan %T", src)
+	}
+}
+
+// Scan implements sql.Scanner. It accepts NULL and an address as string or
+// []byte in textual form, also as a PostgreSQL inet with a prefix length,
+// or as 4 or 16 raw bytes.
+func (i *IP) Scan(src interface{}) error {
+	text, ok, err := scanText(src)
+	if nil != err {
+		return err
+	}
+	if !ok || "" == text {
+		i.IP, i.Zone = nil, ""
+		return nil
+	}
+	if indexI := strings.IndexByte(text, '/'); 0 <= indexI {
+		text = text[:indexI]
+	}
+	if err = i.UnmarshalText([]byte(text)); nil == err {
+		return nil
+	}
+	if b, isBytes := src.([]byte); isBytes && (net.IPv4len == len(b) || net.IPv6len == len(b)) {
+		return i.UnmarshalBinary(b)
+	}
+	return err
+}
+
+// Value implements driver.Valuer. The IP is stored in textual form, which
+// PostgreSQL accepts as inet, or as NULL if it is nil.
+func (i IP) Value() (driver.Value, error) {
+	if nil == i.IP {
+		return nil, nil
+	}
+	text, err := i.MarshalText()
+	if nil != err {
+		return nil, err
+	}
+	return string(text), nil
+}
+
+// Scan implements sql.Scanner. It accepts NULL and a network as string or
+// []byte in CIDR notation, also as a PostgreSQL inet or cidr, where an
+// address without prefix length is fully masked, or in the form of
+// MarshalBinary.
+func (i *IPNet) Scan(src interface{}) error {
+	text, ok, err := scanText(src)
+	if nil != err {
+		return err
+	}
+	if !ok || "" == text {
+		i.IP, i.Mask = nil, nil
+		return nil
+	}
+	ip, n, err := ParseCIDROrIP(text)
+	if nil == err {
+		i.IP = ip.IP
+		i.Mask = n.Mask
+		return nil
+	}
+	if b, isBytes := src.([]byte); isBytes && (net.IPv4len+1 == len(b) || net.IPv6len+1 == len(b)) {
+		return i.UnmarshalBinary(b)
+	}
+	return err
+}
+
+// Value implements driver.Valuer. The IPNet is stored in CIDR notation,
+// which PostgreSQL accepts as inet and, without host bits, as cidr, or as
+// NULL if it has neither IP nor mask.
+func (i IPNet) Value() (driver.Value, error) {
+	if i.isZero() {
+		return nil, nil
+	}
+	text, err := i.MarshalText()
+	if nil != err {
+		return nil, err
+	}
+	return string(text), nil
+}
+
+// Scan implements sql.Scanner. It accepts NULL and an address as string or
+// []byte in one of the formats of net.ParseMAC, also as a PostgreSQL
+// macaddr or macaddr8, or as 6 or 8 raw bytes.
+func (h *HardwareAddr) Scan(src interface{}) error {
+	text, ok, err := scanText(src)
+	if nil != err {
+		return err
+	}
+	if !ok || "" == text {
+		h.HardwareAddr = nil
+		return nil
+	}
+	if err = h.UnmarshalText([]byte(text)); nil == err {
+		return nil
+	}
+	if b, isBytes := src.([]byte); isBytes && (6 == len(b) || 8 == len(b)) {
+		h.HardwareAddr = append(net.HardwareAddr(nil), b...)
+		return nil
+	}
+	return err
+}
+
+// Value implements driver.Valuer. The address is stored as colon separated
+// hex digits, or as NULL if it is nil.
+func (h HardwareAddr) Value() (driver.Value, error) {
+	if nil == h.HardwareAddr {
+		return nil, nil
+	}
+	return h.HardwareAddr.String(), nil
+}
+
+// NullIP is an IP that may be NULL in a database.
+type NullIP struct {
+	IP    IP
+	Valid bool // Valid is true if IP is not NULL
+}
+
+// Scan implements sql.Scanner.
+func (n *NullIP) Scan(src interface{}) error {
+	if nil == src {
+		n.IP, n.Valid = IP{}, false
+		return nil
+	}
+	if err := n.IP.Scan(src); nil != err {
+		n.Valid = false
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+// Value implements driver.Valuer.
+func (n NullIP) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.IP.Value()
+}
+
+// NullIPNet is an IPNet that may be NULL in a database.
+type NullIPNet struct {
+	IPNet IPNet
+	Valid bool // Valid is true if IPNet is not NULL
+}
+
+// Scan implements sql.Scanner.
+func (n *NullIPNet) Scan(src interface{}) error {
+	if nil == src {
+		n.IPNet, n.Valid = IPNet{}, false
+		return nil
+	}
+	if err := n.IPNet.Scan(src); nil != err {
+		n.Valid = false
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+// Value implements driver.Valuer.
+func (n NullIPNet) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.IPNet.Value()
+}
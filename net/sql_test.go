@@ -0,0 +1,146 @@
+package net
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+)
+
+var (
+	_ sql.Scanner   = (*IP)(nil)
+	_ driver.Valuer = IP{}
+	_ sql.Scanner   = (*IPNet)(nil)
+	_ driver.Valuer = IPNet{}
+	_ sql.Scanner   = (*HardwareAddr)(nil)
+	_ driver.Valuer = HardwareAddr{}
+	_ sql.Scanner   = (*NullIP)(nil)
+	_ sql.Scanner   = (*NullIPNet)(nil)
+)
+
+func TestIPScan(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     interface{}
+		want    string
+		wantErr bool
+	}{
+		{"string", "192.168.1.1", "192.168.1.1", false},
+		{"bytes", []byte("2001:db8::1"), "2001:db8::1", false},
+		{"inet", "10.0.0.1/24", "10.0.0.1", false},
+		{"raw", []byte{10, 0, 0, 1}, "10.0.0.1", false},
+		{"raw white space", []byte{10, 10, 10, 10}, "10.10.10.10", false},
+		{"raw white space ipv4", []byte{32, 32, 32, 32}, "32.32.32.32", false},
+		{"white space", []byte{9, 9, 9}, "", true},
+		{"null", nil, "", false},
+		{"invalid", "host", "", true},
+		{"type", 1, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ip IP
+			err := ip.Scan(tt.src)
+			if (nil != err) != tt.wantErr {
+				t.Fatalf("Scan() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			v, err := ip.Value()
+			if nil != err {
+				t.Fatal(err)
+			}
+			if "" == tt.want {
+				if nil != v {
+					t.Errorf("Value() = %v, want nil", v)
+				}
+				return
+			}
+			if tt.want != v {
+				t.Errorf("Value() = %v, want %s", v, tt.want)
+			}
+		})
+	}
+}
+
+func TestIPNetScan(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     interface{}
+		want    string
+		wantErr bool
+	}{
+		{"cidr", "192.168.1.0/24", "192.168.1.0/24", false},
+		{"inet", []byte("192.168.1.7/24"), "192.168.1.7/24", false},
+		{"inet host", "10.0.0.1", "10.0.0.1/32", false},
+		{"ipv6", "2001:db8::/32", "2001:db8::/32", false},
+		{"raw", []byte{10, 0, 0, 0, 8}, "10.0.0.0/8", false},
+		{"raw white space", []byte{10, 10, 10, 10, 32}, "10.10.10.10/32", false},
+		{"invalid", "10.0.0.0/33", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var n IPNet
+			err := n.Scan(tt.src)
+			if (nil != err) != tt.wantErr {
+				t.Fatalf("Scan() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if v, _ := n.Value(); tt.want != v {
+				t.Errorf("Value() = %v, want %s", v, tt.want)
+			}
+		})
+	}
+}
+
+func TestHardwareAddrScan(t *testing.T) {
+	var hw HardwareAddr
+	if err := hw.Scan("08:00:2b:01:02:03"); nil != err {
+		t.Fatal(err)
+	}
+	if v, _ := hw.Value(); "08:00:2b:01:02:03" != v {
+		t.Errorf("Value() = %v", v)
+	}
+	if err := hw.Scan([]byte{8, 0, 0x2b, 1, 2, 3}); nil != err {
+		t.Fatal(err)
+	}
+	if "08:00:2b:01:02:03" != hw.String() {
+		t.Errorf("Scan() = %s", hw)
+	}
+	if err := hw.Scan([]byte{9, 9, 9, 9, 9, 9}); nil != err || "09:09:09:09:09:09" != hw.String() {
+		t.Errorf("Scan() = %s, %v", hw, err)
+	}
+	if err := hw.Scan("08:00"); nil == err {
+		t.Errorf("Scan() error = nil")
+	}
+}
+
+func TestNullScan(t *testing.T) {
+	var ip NullIP
+	if err := ip.Scan(nil); nil != err || ip.Valid {
+		t.Errorf("Scan(nil) = %v, %v", ip, err)
+	}
+	if v, _ := ip.Value(); nil != v {
+		t.Errorf("Value() = %v", v)
+	}
+	if err := ip.Scan("10.0.0.1"); nil != err || !ip.Valid {
+		t.Errorf("Scan() = %v, %v", ip, err)
+	}
+	if err := ip.Scan([]byte{10, 10, 10, 10}); nil != err || !ip.Valid || "10.10.10.10" != ip.IP.String() {
+		t.Errorf("Scan() = %v, %v", ip, err)
+	}
+
+	var n NullIPNet
+	if err := n.Scan("10.0.0.0/8"); nil != err || !n.Valid {
+		t.Errorf("Scan() = %v, %v", n, err)
+	}
+	if v, _ := n.Value(); "10.0.0.0/8" != v {
+		t.Errorf("Value() = %v", v)
+	}
+	if err := n.Scan(nil); nil != err || n.Valid {
+		t.Errorf("Scan(nil) = %v, %v", n, err)
+	}
+}
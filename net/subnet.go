@@ -0,0 +1,140 @@
+package net
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+)
+
+// PrefixLen returns the length of the mask of the IPNet, or -1 if the mask
+// is not canonical or does not match the IP.
+func (i *IPNet) PrefixLen() int {
+	_, ones, err := i.prefix()
+	if nil != err {
+		return -1
+	}
+	return ones
+}
+
+// NumAddresses returns the number of addresses in the IPNet, 0 if it is not
+// valid.
+func (i *IPNet) NumAddresses() *big.Int {
+	ip, ones, err := i.prefix()
+	if nil != err {
+		return new(big.Int)
+	}
+	return new(big.Int).Lsh(big.NewInt(1), uint(len(ip)*8-ones))
+}
+
+// ContainsNet reports whether all addresses of other are in the IPNet.
+// Networks of different families never contain each other.
+func (i *IPNet) ContainsNet(other IPNet) bool {
+	ip, ones, err := i.prefix()
+	if nil != err {
+		return false
+	}
+	otherIP, otherOnes, err := other.prefix()
+	if nil != err || len(ip) != len(otherIP) {
+		return false
+	}
+	return ones <= otherOnes && i.Contains(otherIP)
+}
+
+// Supernet returns the network with the shorter prefixLen containing the
+// IPNet.
+func (i *IPNet) Supernet(prefixLen int) (IPNet, error) {
+	ip, ones, err := i.prefix()
+	if nil != err {
+		return IPNet{}, err
+	}
+	if 0 > prefixLen || ones < prefixLen {
+		return IPNet{}, fmt.Errorf("Net: prefix length %d of supernet of %s must be between 0 and %d", prefixLen, i.String(), ones)
+	}
+	return maskedIPNet(ip, prefixLen), nil
+}
+
+// Halves returns the two networks with a one bit longer prefix which make
+// up the IPNet.
+func (i *IPNet) Halves() (IPNet, IPNet, error) {
+	ip, ones, err := i.prefix()
+	if nil != err {
+		return IPNet{}, IPNet{}, err
+	}
+	if len(ip)*8 == ones {
+		return IPNet{}, IPNet{}, fmt.Errorf("Net: %s can not be halved", i.String())
+	}
+	lower := maskedIPNet(ip, ones+1)
+	upper := maskedIPNet(ip, ones+1)
+	upper.IP[ones/8] |= 0x80 >> uint(ones%8)
+	return lower, upper, nil
+}
+
+// Subnets returns an iterator over the subnets with newPrefixLen of the
+// IPNet in increasing order. Subnets are computed as they are iterated, so
+// that also large IPv6 networks can be split.
+func (i *IPNet) Subnets(newPrefixLen int) (*SubnetIterator, error) {
+	ip, ones, err := i.prefix()
+	if nil != err {
+		return nil, err
+	}
+	bits := len(ip) * 8
+	if ones > newPrefixLen || bits < newPrefixLen {
+		return nil, fmt.Errorf("Net: prefix length %d of subnets of %s must be between %d and %d", newPrefixLen, i.String(), ones, bits)
+	}
+
+	first := maskedIPNet(ip, ones)
+	return &SubnetIterator{
+		next:      ipToBig(first.IP),
+		remaining: new(big.Int).Lsh(big.NewInt(1), uint(newPrefixLen-ones)),
+		step:      new(big.Int).Lsh(big.NewInt(1), uint(bits-newPrefixLen)),
+		size:      len(ip),
+		prefixLen: newPrefixLen,
+	}, nil
+}
+
+// SubnetIterator iterates over the subnets of an IPNet.
+//
+//	it, err := n.Subnets(24)
+//	for it.Next() {
+//		subnet := it.Subnet()
+//	}
+type SubnetIterator struct {
+	next      *big.Int
+	remaining *big.Int
+	step      *big.Int
+	size      int
+	prefixLen int
+	current   IPNet
+}
+
+// Next advances to the next subnet and reports whether there is one.
+func (it *SubnetIterator) Next() bool {
+	if 0 == it.remaining.Sign() {
+		return false
+	}
+	ip := make(net.IP, it.size)
+	it.next.FillBytes(ip)
+	it.current = IPNet{net.IPNet{IP: ip, Mask: net.CIDRMask(it.prefixLen, it.size*8)}}
+
+	it.remaining.Sub(it.remaining, big.NewInt(1))
+	if 0 != it.remaining.Sign() {
+		it.next.Add(it.next, it.step)
+	}
+	return true
+}
+
+// Subnet returns the current subnet.
+func (it *SubnetIterator) Subnet() IPNet {
+	return it.current
+}
+
+// Remaining returns the number of subnets not yet iterated.
+func (it *SubnetIterator) Remaining() *big.Int {
+	return new(big.Int).Set(it.remaining)
+}
+
+// maskedIPNet returns the network of ip with prefix length ones.
+func maskedIPNet(ip net.IP, ones int) IPNet {
+	mask := net.CIDRMask(ones, len(ip)*8)
+	return IPNet{net.IPNet{IP: ip.Mask(mask), Mask: mask}}
+}
@@ -0,0 +1,111 @@
+package net
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestIPNetSubnets(t *testing.T) {
+	n := MustParseNetwork("10.0.0.0/22")
+	it, err := n.Subnets(24)
+	if nil != err {
+		t.Fatal(err)
+	}
+	var got []string
+	for it.Next() {
+		got = append(got, it.Subnet().String())
+	}
+	want := []string{"10.0.0.0/24", "10.0.1.0/24", "10.0.2.0/24", "10.0.3.0/24"}
+	if len(want) != len(got) {
+		t.Fatalf("Subnets() = %v, want %v", got, want)
+	}
+	for indexI := range want {
+		if want[indexI] != got[indexI] {
+			t.Errorf("Subnets()[%d] = %s, want %s", indexI, got[indexI], want[indexI])
+		}
+	}
+
+	n = MustParseNetwork("2001:db8::/32")
+	if it, err = n.Subnets(96); nil != err {
+		t.Fatal(err)
+	}
+	if 0 != it.Remaining().Cmp(new(big.Int).Lsh(big.NewInt(1), 64)) {
+		t.Errorf("Remaining() = %s", it.Remaining())
+	}
+	it.Next()
+	it.Next()
+	if "2001:db8::1:0:0/96" != it.Subnet().String() {
+		t.Errorf("Subnet() = %s", it.Subnet().String())
+	}
+
+	if _, err = n.Subnets(16); nil == err {
+		t.Errorf("Subnets(16) of /32 error = nil")
+	}
+	if _, err = n.Subnets(129); nil == err {
+		t.Errorf("Subnets(129) error = nil")
+	}
+}
+
+func TestIPNetSupernet(t *testing.T) {
+	tests := []struct {
+		n         string
+		prefixLen int
+		want      string
+		wantErr   bool
+	}{
+		{"192.168.1.0/24", 16, "192.168.0.0/16", false},
+		{"192.168.1.0/24", 0, "0.0.0.0/0", false},
+		{"192.168.1.0/24", 25, "", true},
+		{"2001:db8:1::/48", 32, "2001:db8::/32", false},
+	}
+
+	for _, tt := range tests {
+		n := MustParseNetwork(tt.n)
+		got, err := n.Supernet(tt.prefixLen)
+		if (nil != err) != tt.wantErr {
+			t.Fatalf("Supernet() error = %v, wantErr %v", err, tt.wantErr)
+		}
+		if !tt.wantErr && tt.want != got.String() {
+			t.Errorf("Supernet(%d) of %s = %s, want %s", tt.prefixLen, tt.n, got.String(), tt.want)
+		}
+	}
+}
+
+func TestIPNetHalves(t *testing.T) {
+	n := MustParseNetwork("10.0.0.0/23")
+	lower, upper, err := n.Halves()
+	if nil != err || "10.0.0.0/24" != lower.String() || "10.0.1.0/24" != upper.String() {
+		t.Errorf("Halves() = %s, %s, %v", lower.String(), upper.String(), err)
+	}
+	n = MustParseNetwork("2001:db8::/32")
+	lower, upper, err = n.Halves()
+	if nil != err || "2001:db8::/33" != lower.String() || "2001:db8:8000::/33" != upper.String() {
+		t.Errorf("Halves() = %s, %s, %v", lower.String(), upper.String(), err)
+	}
+	n = MustParseNetwork("10.0.0.1/32")
+	if _, _, err = n.Halves(); nil == err {
+		t.Errorf("Halves() of /32 error = nil")
+	}
+}
+
+func TestIPNetSize(t *testing.T) {
+	n := MustParseNetwork("10.0.0.0/8")
+	if 8 != n.PrefixLen() || 0 != n.NumAddresses().Cmp(big.NewInt(1<<24)) {
+		t.Errorf("PrefixLen() = %d, NumAddresses() = %s", n.PrefixLen(), n.NumAddresses())
+	}
+	n = MustParseNetwork("::/0")
+	if 0 != n.PrefixLen() || 129 != n.NumAddresses().BitLen() {
+		t.Errorf("PrefixLen() = %d, NumAddresses() = %s", n.PrefixLen(), n.NumAddresses())
+	}
+
+	n = MustParseNetwork("10.0.0.0/8")
+	if !n.ContainsNet(MustParseNetwork("10.1.0.0/16")) {
+		t.Errorf("ContainsNet() of subnet = false")
+	}
+	if n.ContainsNet(MustParseNetwork("0.0.0.0/0")) {
+		t.Errorf("ContainsNet() of supernet = true")
+	}
+	if n.ContainsNet(MustParseNetwork("::/0")) {
+		t.Errorf("ContainsNet() of IPv6 = true")
+	}
+}
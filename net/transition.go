@@ -0,0 +1,221 @@
+package net
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+)
+
+var (
+	// NAT64WellKnownPrefix is the Well-Known Prefix of RFC 6052.
+	NAT64WellKnownPrefix = MustParseNetwork("64:ff9b::/96")
+
+	ipv4MappedPrefix = []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0xff, 0xff}
+	teredoPrefix     = []byte{0x20, 0x01, 0, 0}
+)
+
+// ParseIPExact returns an IP from a string like ParseIP, but keeps an
+// address in IPv6 notation as 16-bytes, so that IPv4-mapped addresses such
+// as "::ffff:192.0.2.1" can be told apart from IPv4 addresses.
+func ParseIPExact(s string) *IP {
+	ip := ParseIP(s)
+	if nil != ip && strings.Contains(s, ":") {
+		ip.IP = ip.IP.To16()
+	}
+	return ip
+}
+
+// ipv6 returns the IP if it is 16-bytes, nil otherwise.
+func (i IP) ipv6() net.IP {
+	if net.IPv6len != len(i.IP) {
+		return nil
+	}
+	return i.IP
+}
+
+// IPv4Mapped returns the IPv4 address of an IPv4-mapped IPv6 address
+// "::ffff:a.b.c.d". IPs of 4 bytes are not IPv6 addresses, so ok is false.
+func (i IP) IPv4Mapped() (ip IP, ok bool) {
+	if net.IPv6len != len(i.IP) || !hasPrefix(i.IP, ipv4MappedPrefix) {
+		return IP{}, false
+	}
+	return ipv4Of(i.IP[12:]), true
+}
+
+// IPv4Compatible returns the IPv4 address of a deprecated IPv4-compatible
+// IPv6 address "::a.b.c.d". The unspecified and the loopback address are
+// not IPv4-compatible.
+func (i IP) IPv4Compatible() (ip IP, ok bool) {
+	addr := i.ipv6()
+	if nil == addr || !hasPrefix(addr, make([]byte, 12)) || 1 >= binary.BigEndian.Uint32(addr[12:]) {
+		return IP{}, false
+	}
+	return ipv4Of(addr[12:]), true
+}
+
+// SixToFour returns the IPv4 address embedded in a 6to4 address of
+// 2002::/16.
+func (i IP) SixToFour() (ip IP, ok bool) {
+	addr := i.ipv6()
+	if nil == addr || 0x20 != addr[0] || 0x02 != addr[1] {
+		return IP{}, false
+	}
+	return ipv4Of(addr[2:6]), true
+}
+
+// NAT64 returns the IPv4 address embedded in the IP by a NAT64 prefix as in
+// RFC 6052. The prefix length must be 32, 40, 48, 56, 64 or 96.
+func (i IP) NAT64(prefix IPNet) (IP, error) {
+	addr := i.ipv6()
+	ones, err := nat64PrefixLen(prefix)
+	if nil != err {
+		return IP{}, err
+	}
+	if nil == addr || !prefix.Contains(addr) {
+		return IP{}, fmt.Errorf("Net: %v is not in NAT64 prefix %s", i.IP, prefix.String())
+	}
+	v4 := make([]byte, 0, net.IPv4len)
+	for _, position := range nat64Positions(ones) {
+		v4 = append(v4, addr[position])
+	}
+	return ipv4Of(v4), nil
+}
+
+// NewNAT64 returns the IPv6 address of ip in a NAT64 prefix as in RFC 6052.
+// The prefix length must be 32, 40, 48, 56, 64 or 96.
+func NewNAT64(prefix IPNet, ip IP) (IP, error) {
+	ones, err := nat64PrefixLen(prefix)
+	if nil != err {
+		return IP{}, err
+	}
+	v4 := ip.IP.To4()
+	if nil == v4 {
+		return IP{}, fmt.Errorf("Net: %v is not an IPv4 address", ip.IP)
+	}
+	addr := make(net.IP, net.IPv6len)
+	copy(addr, prefix.IP.To16()[:ones/8])
+	for indexI, position := range nat64Positions(ones) {
+		addr[position] = v4[indexI]
+	}
+	return IP{IP: addr}, nil
+}
+
+func nat64PrefixLen(prefix IPNet) (int, error) {
+	ip, ones, err := prefix.prefix()
+	if nil != err {
+		return 0, err
+	}
+	if net.IPv6len != len(ip) {
+		return 0, fmt.Errorf("Net: NAT64 prefix %s is not IPv6", prefix.String())
+	}
+	switch ones {
+	case 32, 40, 48, 56, 64, 96:
+		return ones, nil
+	}
+	return 0, fmt.Errorf("Net: invalid NAT64 prefix length %d", ones)
+}
+
+// nat64Positions returns the bytes of an IPv6 address holding the IPv4
+// address for a prefix length, skipping the reserved bits 64 to 71.
+func nat64Positions(ones int) []int {
+	positions := make([]int, 0, net.IPv4len)
+	for position := ones / 8; net.IPv4len > len(positions); position++ {
+		if 8 != position {
+			positions = append(positions, position)
+		}
+	}
+	return positions
+}
+
+// NewIPv4Mapped returns the IPv4-mapped IPv6 address "::ffff:a.b.c.d" of
+// an IPv4 address, as 16-bytes.
+func NewIPv4Mapped(ip IP) (IP, error) {
+	v4 := ip.IP.To4()
+	if nil == v4 {
+		return IP{}, fmt.Errorf("Net: %v is not an IPv4 address", ip.IP)
+	}
+	addr := make(net.IP, 0, net.IPv6len)
+	addr = append(addr, ipv4MappedPrefix...)
+	return IP{IP: append(addr, v4...)}, nil
+}
+
+// NewIPv4Compatible returns the deprecated IPv4-compatible IPv6 address
+// "::a.b.c.d" of an IPv4 address.
+func NewIPv4Compatible(ip IP) (IP, error) {
+	v4 := ip.IP.To4()
+	if nil == v4 {
+		return IP{}, fmt.Errorf("Net: %v is not an IPv4 address", ip.IP)
+	}
+	addr := make(net.IP, net.IPv6len)
+	copy(addr[12:], v4)
+	return IP{IP: addr}, nil
+}
+
+// NewSixToFour returns the 6to4 prefix 2002:aabb:ccdd::/48 of the IPv4
+// address a.b.c.d.
+func NewSixToFour(ip IP) (IPNet, error) {
+	v4 := ip.IP.To4()
+	if nil == v4 {
+		return IPNet{}, fmt.Errorf("Net: %v is not an IPv4 address", ip.IP)
+	}
+	addr := make(net.IP, net.IPv6len)
+	addr[0], addr[1] = 0x20, 0x02
+	copy(addr[2:6], v4)
+	return IPNet{net.IPNet{IP: addr, Mask: net.CIDRMask(48, net.IPv6len*8)}}, nil
+}
+
+// Teredo is what a Teredo address of 2001::/32 holds as in RFC 4380.
+type Teredo struct {
+	// Server is the IPv4 address of the Teredo server.
+	Server IP
+	Flags  uint16
+	// Client and Port are the external IPv4 address and UDP port of the
+	// client, which are stored obfuscated.
+	Client IP
+	Port   uint16
+}
+
+// Teredo returns the parts of a Teredo address.
+func (i IP) Teredo() (*Teredo, bool) {
+	addr := i.ipv6()
+	if nil == addr || !hasPrefix(addr, teredoPrefix) {
+		return nil, false
+	}
+	client := make([]byte, net.IPv4len)
+	for indexI := range client {
+		client[indexI] = ^addr[12+indexI]
+	}
+	return &Teredo{
+		Server: ipv4Of(addr[4:8]),
+		Flags:  binary.BigEndian.Uint16(addr[8:10]),
+		Client: IP{IP: client},
+		Port:   ^binary.BigEndian.Uint16(addr[10:12]),
+	}, true
+}
+
+// NewTeredo returns the Teredo address of t.
+func NewTeredo(t Teredo) (IP, error) {
+	server, client := t.Server.IP.To4(), t.Client.IP.To4()
+	if nil == server || nil == client {
+		return IP{}, fmt.Errorf("Net: Teredo server %v and client %v must be IPv4 addresses", t.Server.IP, t.Client.IP)
+	}
+	addr := make(net.IP, net.IPv6len)
+	copy(addr, teredoPrefix)
+	copy(addr[4:8], server)
+	binary.BigEndian.PutUint16(addr[8:10], t.Flags)
+	binary.BigEndian.PutUint16(addr[10:12], ^t.Port)
+	for indexI := range client {
+		addr[12+indexI] = ^client[indexI]
+	}
+	return IP{IP: addr}, nil
+}
+
+func hasPrefix(ip net.IP, prefix []byte) bool {
+	return len(prefix) <= len(ip) && string(prefix) == string(ip[:len(prefix)])
+}
+
+// ipv4Of returns a copy of 4 bytes as an IP.
+func ipv4Of(b []byte) IP {
+	return IP{IP: append(net.IP(nil), b...)}
+}
@@ -0,0 +1,96 @@
+package net
+
+import (
+	"testing"
+)
+
+func TestIPv4Embedded(t *testing.T) {
+	mapped := ParseIPExact("::ffff:192.0.2.1")
+	if 16 != len(mapped.IP) {
+		t.Fatalf("ParseIPExact() = %d bytes", len(mapped.IP))
+	}
+	if v4, ok := mapped.IPv4Mapped(); !ok || "192.0.2.1" != v4.String() {
+		t.Errorf("IPv4Mapped() = %s, %v", v4, ok)
+	}
+	if _, ok := ParseIP("::ffff:192.0.2.1").IPv4Mapped(); ok {
+		t.Errorf("IPv4Mapped() of 4-byte IP ok")
+	}
+	if ip, _ := NewIPv4Mapped(MustParseIP("192.0.2.1")); !ip.Equal(mapped.IP) || 16 != len(ip.IP) {
+		t.Errorf("NewIPv4Mapped() = %v", ip.IP)
+	}
+
+	compatible, _ := NewIPv4Compatible(MustParseIP("192.0.2.1"))
+	if "::c000:201" != compatible.String() {
+		t.Errorf("NewIPv4Compatible() = %s", compatible)
+	}
+	if v4, ok := compatible.IPv4Compatible(); !ok || "192.0.2.1" != v4.String() {
+		t.Errorf("IPv4Compatible() = %s, %v", v4, ok)
+	}
+	if _, ok := MustParseIP("::1").IPv4Compatible(); ok {
+		t.Errorf("IPv4Compatible() of ::1 ok")
+	}
+
+	prefix, _ := NewSixToFour(MustParseIP("192.0.2.1"))
+	if "2002:c000:201::/48" != prefix.String() {
+		t.Errorf("NewSixToFour() = %s", prefix.String())
+	}
+	if v4, ok := MustParseIP("2002:c000:201::1").SixToFour(); !ok || "192.0.2.1" != v4.String() {
+		t.Errorf("SixToFour() = %s, %v", v4, ok)
+	}
+}
+
+func TestNAT64(t *testing.T) {
+	// The examples of RFC 6052, section 2.4.
+	tests := []struct {
+		prefix string
+		want   string
+	}{
+		{"2001:db8::/32", "2001:db8:c000:221::"},
+		{"2001:db8:100::/40", "2001:db8:1c0:2:21::"},
+		{"2001:db8:122::/48", "2001:db8:122:c000:2:2100::"},
+		{"2001:db8:122:300::/56", "2001:db8:122:3c0:0:221::"},
+		{"2001:db8:122:344::/64", "2001:db8:122:344:c0:2:2100:0"},
+		{"2001:db8:122:344::/96", "2001:db8:122:344::c000:221"},
+		{"64:ff9b::/96", "64:ff9b::c000:221"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			prefix := MustParseNetwork(tt.prefix)
+			ip, err := NewNAT64(prefix, MustParseIP("192.0.2.33"))
+			if nil != err || tt.want != ip.String() {
+				t.Fatalf("NewNAT64() = %s, %v, want %s", ip, err, tt.want)
+			}
+			v4, err := ip.NAT64(prefix)
+			if nil != err || "192.0.2.33" != v4.String() {
+				t.Errorf("NAT64() = %s, %v", v4, err)
+			}
+		})
+	}
+
+	if _, err := NewNAT64(MustParseNetwork("2001:db8::/33"), MustParseIP("192.0.2.33")); nil == err {
+		t.Errorf("NewNAT64() with /33 error = nil")
+	}
+	if _, err := MustParseIP("2001:db9::1").NAT64(NAT64WellKnownPrefix); nil == err {
+		t.Errorf("NAT64() outside prefix error = nil")
+	}
+}
+
+func TestTeredo(t *testing.T) {
+	// The example of RFC 4380, section 4.
+	ip := MustParseIP("2001:0:4136:e378:8000:63bf:3fff:fdd2")
+	teredo, ok := ip.Teredo()
+	if !ok {
+		t.Fatal("Teredo() not ok")
+	}
+	if "65.54.227.120" != teredo.Server.String() || "192.0.2.45" != teredo.Client.String() || 40000 != teredo.Port || 0x8000 != teredo.Flags {
+		t.Errorf("Teredo() = %+v", teredo)
+	}
+	back, err := NewTeredo(*teredo)
+	if nil != err || !back.Equal(ip.IP) {
+		t.Errorf("NewTeredo() = %s, %v", back, err)
+	}
+	if _, ok = MustParseIP("2001:db8::1").Teredo(); ok {
+		t.Errorf("Teredo() of other address ok")
+	}
+}
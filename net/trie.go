@@ -0,0 +1,272 @@
+package net
+
+import (
+	"net"
+)
+
+// Trie maps networks to values and finds the networks containing an IP by
+// longest-prefix match. It is a path-compressed binary trie holding IPv4
+// and IPv6 networks. The zero value is an empty Trie.
+//
+// A Trie is not safe for concurrent use if it is modified.
+type Trie struct {
+	v4   *trieNode
+	v6   *trieNode
+	size int
+}
+
+// TrieEntry is a network and its value in a Trie.
+type TrieEntry struct {
+	Net   IPNet
+	Value interface{}
+}
+
+// trieNode is a prefix of length bits. Keys are left-aligned, so that bit
+// indexes are the same for both families.
+type trieNode struct {
+	key      uint128
+	length   int
+	value    interface{}
+	hasValue bool
+	children [2]*trieNode
+}
+
+// trieKey returns the left-aligned key and the size of n in bytes.
+func trieKey(n IPNet) (uint128, int, int, error) {
+	ip, ones, err := n.prefix()
+	if nil != err {
+		return uint128{}, 0, 0, err
+	}
+	return alignKey(uint128Of(ip), len(ip)).prefix(ones), ones, len(ip), nil
+}
+
+// alignKey shifts the integer of an IPv4 address to the left.
+func alignKey(u uint128, size int) uint128 {
+	if net.IPv4len == size {
+		return uint128{hi: u.lo << 32}
+	}
+	return u
+}
+
+// matches reports whether the first length bits of key are the prefix of
+// the node.
+func (n *trieNode) matches(key uint128, length int) bool {
+	return n.length <= length && key.prefix(n.length) == n.key
+}
+
+func (n *trieNode) entry(size int) TrieEntry {
+	u := n.key
+	if net.IPv4len == size {
+		u = uint128{lo: u.hi >> 32}
+	}
+	ip := u.ip(size).IP
+	return TrieEntry{
+		Net:   IPNet{net.IPNet{IP: ip, Mask: net.CIDRMask(n.length, size*8)}},
+		Value: n.value,
+	}
+}
+
+func (t *Trie) root(size int) **trieNode {
+	if net.IPv4len == size {
+		return &t.v4
+	}
+	return &t.v6
+}
+
+// Len returns the number of networks in the Trie.
+func (t *Trie) Len() int {
+	return t.size
+}
+
+// Insert sets the value of n, whose host bits are ignored, and replaces a
+// previous value.
+func (t *Trie) Insert(n IPNet, value interface{}) error {
+	key, length, size, err := trieKey(n)
+	if nil != err {
+		return err
+	}
+
+	pp := t.root(size)
+	for {
+		node := *pp
+		if nil == node {
+			*pp = &trieNode{key: key, length: length, value: value, hasValue: true}
+			t.size++
+			return nil
+		}
+
+		common := node.key.xor(key).leadingZeros()
+		if common > node.length {
+			common = node.length
+		}
+		if common > length {
+			common = length
+		}
+
+		switch {
+		case common == node.length && common == length:
+			if !node.hasValue {
+				t.size++
+			}
+			node.value, node.hasValue = value, true
+			return nil
+		case common == node.length:
+			pp = &node.children[key.bit(node.length)]
+		case common == length:
+			parent := &trieNode{key: key, length: length, value: value, hasValue: true}
+			parent.children[node.key.bit(length)] = node
+			*pp = parent
+			t.size++
+			return nil
+		default:
+			glue := &trieNode{key: key.prefix(common), length: common}
+			glue.children[key.bit(common)] = &trieNode{key: key, length: length, value: value, hasValue: true}
+			glue.children[node.key.bit(common)] = node
+			*pp = glue
+			t.size++
+			return nil
+		}
+	}
+}
+
+// Delete removes n and reports whether it was in the Trie.
+func (t *Trie) Delete(n IPNet) bool {
+	key, length, size, err := trieKey(n)
+	if nil != err {
+		return false
+	}
+	if !deleteNode(t.root(size), key, length) {
+		return false
+	}
+	t.size--
+	return true
+}
+
+func deleteNode(pp **trieNode, key uint128, length int) bool {
+	node := *pp
+	if nil == node || !node.matches(key, length) {
+		return false
+	}
+	if node.length == length {
+		if !node.hasValue {
+			return false
+		}
+		node.value, node.hasValue = nil, false
+	} else if !deleteNode(&node.children[key.bit(node.length)], key, length) {
+		return false
+	}
+
+	// Nodes without value are kept only to join two subtrees.
+	if !node.hasValue {
+		switch {
+		case nil == node.children[0]:
+			*pp = node.children[1]
+		case nil == node.children[1]:
+			*pp = node.children[0]
+		}
+	}
+	return true
+}
+
+// Get returns the value of exactly n.
+func (t *Trie) Get(n IPNet) (interface{}, bool) {
+	key, length, size, err := trieKey(n)
+	if nil != err {
+		return nil, false
+	}
+	for node := *t.root(size); nil != node && node.matches(key, length); node = node.children[key.bit(node.length)] {
+		if node.length == length {
+			return node.value, node.hasValue
+		}
+	}
+	return nil, false
+}
+
+// LongestMatch returns the most specific network containing ip and its
+// value, ok is false if there is none.
+func (t *Trie) LongestMatch(ip IP) (n IPNet, value interface{}, ok bool) {
+	addr := ip.normalized()
+	if nil == addr {
+		return IPNet{}, nil, false
+	}
+	key, length := alignKey(uint128Of(addr), len(addr)), len(addr)*8
+
+	var match *trieNode
+	for node := *t.root(len(addr)); nil != node && node.matches(key, length); {
+		if node.hasValue {
+			match = node
+		}
+		if node.length == length {
+			break
+		}
+		node = node.children[key.bit(node.length)]
+	}
+	if nil == match {
+		return IPNet{}, nil, false
+	}
+	entry := match.entry(len(addr))
+	return entry.Net, entry.Value, true
+}
+
+// Covering returns the networks containing n, including n itself, from the
+// least to the most specific.
+func (t *Trie) Covering(n IPNet) []TrieEntry {
+	key, length, size, err := trieKey(n)
+	if nil != err {
+		return nil
+	}
+	var entries []TrieEntry
+	for node := *t.root(size); nil != node && node.matches(key, length); {
+		if node.hasValue {
+			entries = append(entries, node.entry(size))
+		}
+		if node.length == length {
+			break
+		}
+		node = node.children[key.bit(node.length)]
+	}
+	return entries
+}
+
+// Covered returns the networks contained in n, including n itself, in
+// increasing order of their addresses.
+func (t *Trie) Covered(n IPNet) []TrieEntry {
+	key, length, size, err := trieKey(n)
+	if nil != err {
+		return nil
+	}
+	node := *t.root(size)
+	for nil != node && node.length < length {
+		if !node.matches(key, length) {
+			return nil
+		}
+		node = node.children[key.bit(node.length)]
+	}
+	if nil == node || node.key.prefix(length) != key {
+		return nil
+	}
+	var entries []TrieEntry
+	walkNode(node, size, func(entry TrieEntry) bool {
+		entries = append(entries, entry)
+		return true
+	})
+	return entries
+}
+
+// Walk calls fn for the networks of the Trie in increasing order of their
+// addresses, IPv4 before IPv6, until fn returns false.
+func (t *Trie) Walk(fn func(entry TrieEntry) bool) {
+	if walkNode(t.v4, net.IPv4len, fn) {
+		walkNode(t.v6, net.IPv6len, fn)
+	}
+}
+
+func walkNode(node *trieNode, size int, fn func(entry TrieEntry) bool) bool {
+	if nil == node {
+		return true
+	}
+	if node.hasValue && !fn(node.entry(size)) {
+		return false
+	}
+	return walkNode(node.children[0], size, fn) && walkNode(node.children[1], size, fn)
+}
@@ -0,0 +1,143 @@
+package net
+
+import (
+	"math/rand"
+	"net"
+	"testing"
+)
+
+func TestTrie(t *testing.T) {
+	var trie Trie
+	prefixes := []string{"10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24", "10.2.0.0/16", "0.0.0.0/0", "2001:db8::/32", "2001:db8:1::/48"}
+	for indexI, prefix := range prefixes {
+		if err := trie.Insert(MustParseNetwork(prefix), indexI); nil != err {
+			t.Fatal(err)
+		}
+	}
+	if len(prefixes) != trie.Len() {
+		t.Errorf("Len() = %d", trie.Len())
+	}
+
+	matches := []struct {
+		ip   string
+		want string
+		ok   bool
+	}{
+		{"10.1.2.3", "10.1.2.0/24", true},
+		{"10.1.3.3", "10.1.0.0/16", true},
+		{"10.3.0.1", "10.0.0.0/8", true},
+		{"192.168.0.1", "0.0.0.0/0", true},
+		{"2001:db8:1::1", "2001:db8:1::/48", true},
+		{"2001:db9::1", "", false},
+	}
+	for _, tt := range matches {
+		n, _, ok := trie.LongestMatch(MustParseIP(tt.ip))
+		if ok != tt.ok || (ok && tt.want != n.String()) {
+			t.Errorf("LongestMatch(%s) = %s, %v, want %s", tt.ip, n.String(), ok, tt.want)
+		}
+	}
+
+	if v, ok := trie.Get(MustParseCIDR("10.1.7.7/16")); !ok || 1 != v {
+		t.Errorf("Get() = %v, %v", v, ok)
+	}
+	if _, ok := trie.Get(MustParseNetwork("10.1.0.0/17")); ok {
+		t.Errorf("Get() of missing prefix ok")
+	}
+
+	var covering []string
+	for _, entry := range trie.Covering(MustParseNetwork("10.1.2.0/24")) {
+		covering = append(covering, entry.Net.String())
+	}
+	if "0.0.0.0/0 10.0.0.0/8 10.1.0.0/16 10.1.2.0/24" != joinStrings(covering) {
+		t.Errorf("Covering() = %v", covering)
+	}
+	var covered []string
+	for _, entry := range trie.Covered(MustParseNetwork("10.0.0.0/8")) {
+		covered = append(covered, entry.Net.String())
+	}
+	if "10.0.0.0/8 10.1.0.0/16 10.1.2.0/24 10.2.0.0/16" != joinStrings(covered) {
+		t.Errorf("Covered() = %v", covered)
+	}
+
+	if !trie.Delete(MustParseNetwork("10.1.0.0/16")) || trie.Delete(MustParseNetwork("10.1.0.0/16")) {
+		t.Errorf("Delete() is wrong")
+	}
+	if n, _, _ := trie.LongestMatch(MustParseIP("10.1.3.3")); "10.0.0.0/8" != n.String() {
+		t.Errorf("LongestMatch() after Delete() = %s", n.String())
+	}
+	if n, _, _ := trie.LongestMatch(MustParseIP("10.1.2.3")); "10.1.2.0/24" != n.String() {
+		t.Errorf("LongestMatch() after Delete() = %s", n.String())
+	}
+	if len(prefixes)-1 != trie.Len() {
+		t.Errorf("Len() = %d", trie.Len())
+	}
+}
+
+func joinStrings(s []string) string {
+	result := ""
+	for indexI, v := range s {
+		if 0 < indexI {
+			result += " "
+		}
+		result += v
+	}
+	return result
+}
+
+func randomNetworks(r *rand.Rand, count int) []IPNet {
+	nets := make([]IPNet, count)
+	for indexI := range nets {
+		ip := make(net.IP, net.IPv4len)
+		r.Read(ip)
+		ones := 8 + r.Intn(25)
+		nets[indexI] = maskedIPNet(ip, ones)
+	}
+	return nets
+}
+
+func TestTrieRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	nets := randomNetworks(r, 2000)
+
+	var trie Trie
+	for indexI, n := range nets {
+		trie.Insert(n, indexI)
+	}
+	for indexI := 0; indexI < len(nets); indexI += 3 {
+		trie.Delete(nets[indexI])
+	}
+	present := func(n IPNet) bool {
+		_, ok := trie.Get(n)
+		return ok
+	}
+
+	for indexI := 0; indexI < 2000; indexI++ {
+		ip := make(net.IP, net.IPv4len)
+		r.Read(ip)
+		want := -1
+		for _, n := range nets {
+			if n.Contains(ip) && present(n) && n.PrefixLen() > want {
+				want = n.PrefixLen()
+			}
+		}
+		n, _, ok := trie.LongestMatch(IP{IP: ip})
+		if (-1 != want) != ok || (ok && want != n.PrefixLen()) {
+			t.Fatalf("LongestMatch(%s) = %s, %v, want /%d", ip, n.String(), ok, want)
+		}
+	}
+}
+
+func BenchmarkTrieLongestMatch(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	var trie Trie
+	for indexI, n := range randomNetworks(r, 10000) {
+		trie.Insert(n, indexI)
+	}
+	ip := MustParseIP("10.20.30.40")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for indexI := 0; indexI < b.N; indexI++ {
+		trie.LongestMatch(ip)
+	}
+}
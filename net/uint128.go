@@ -0,0 +1,129 @@
+package net
+
+import (
+	"encoding/binary"
+	"math/big"
+	"math/bits"
+	"net"
+)
+
+// uint128 is an IPv6 address, or an IPv4 address in lo, as an unsigned
+// integer.
+type uint128 struct {
+	hi, lo uint64
+}
+
+// uint128Of returns ip, which must be normalized, as an uint128.
+func uint128Of(ip net.IP) uint128 {
+	if net.IPv4len == len(ip) {
+		return uint128{lo: uint64(binary.BigEndian.Uint32(ip))}
+	}
+	return uint128{hi: binary.BigEndian.Uint64(ip[:8]), lo: binary.BigEndian.Uint64(ip[8:])}
+}
+
+// ip returns u as an IP of size bytes.
+func (u uint128) ip(size int) IP {
+	ip := make(net.IP, size)
+	if net.IPv4len == size {
+		binary.BigEndian.PutUint32(ip, uint32(u.lo))
+	} else {
+		binary.BigEndian.PutUint64(ip[:8], u.hi)
+		binary.BigEndian.PutUint64(ip[8:], u.lo)
+	}
+	return IP{IP: ip}
+}
+
+func (u uint128) cmp(v uint128) int {
+	switch {
+	case u.hi < v.hi:
+		return -1
+	case u.hi > v.hi:
+		return 1
+	case u.lo < v.lo:
+		return -1
+	case u.lo > v.lo:
+		return 1
+	}
+	return 0
+}
+
+func (u uint128) isZero() bool {
+	return 0 == u.hi && 0 == u.lo
+}
+
+func (u uint128) add(v uint128) uint128 {
+	lo, carry := bits.Add64(u.lo, v.lo, 0)
+	hi, _ := bits.Add64(u.hi, v.hi, carry)
+	return uint128{hi: hi, lo: lo}
+}
+
+func (u uint128) sub(v uint128) uint128 {
+	lo, borrow := bits.Sub64(u.lo, v.lo, 0)
+	hi, _ := bits.Sub64(u.hi, v.hi, borrow)
+	return uint128{hi: hi, lo: lo}
+}
+
+func (u uint128) addOne() uint128 {
+	return u.add(uint128{lo: 1})
+}
+
+func (u uint128) subOne() uint128 {
+	return u.sub(uint128{lo: 1})
+}
+
+// pow2 returns 2^n for n < 128.
+func pow2(n int) uint128 {
+	if 64 <= n {
+		return uint128{hi: 1 << uint(n-64)}
+	}
+	return uint128{lo: 1 << uint(n)}
+}
+
+// trailingZeros returns the number of trailing zero bits of u, 128 for 0.
+func (u uint128) trailingZeros() int {
+	if 0 != u.lo {
+		return bits.TrailingZeros64(u.lo)
+	}
+	return 64 + bits.TrailingZeros64(u.hi)
+}
+
+func (u uint128) big() *big.Int {
+	n := new(big.Int).SetUint64(u.hi)
+	n.Lsh(n, 64)
+	return n.Or(n, new(big.Int).SetUint64(u.lo))
+}
+
+// hostMask returns 2^n-1 for n <= 128.
+func hostMask(n int) uint128 {
+	if 128 == n {
+		return uint128{hi: ^uint64(0), lo: ^uint64(0)}
+	}
+	return pow2(n).subOne()
+}
+
+// bit returns bit i of u, counted from the most significant bit.
+func (u uint128) bit(i int) int {
+	if 64 > i {
+		return int(u.hi>>uint(63-i)) & 1
+	}
+	return int(u.lo>>uint(127-i)) & 1
+}
+
+// leadingZeros returns the number of leading zero bits of u, 128 for 0.
+func (u uint128) leadingZeros() int {
+	if 0 != u.hi {
+		return bits.LeadingZeros64(u.hi)
+	}
+	return 64 + bits.LeadingZeros64(u.lo)
+}
+
+// xor returns u ^ v.
+func (u uint128) xor(v uint128) uint128 {
+	return uint128{hi: u.hi ^ v.hi, lo: u.lo ^ v.lo}
+}
+
+// prefix returns the n most significant bits of u.
+func (u uint128) prefix(n int) uint128 {
+	m := hostMask(128 - n)
+	return uint128{hi: u.hi &^ m.hi, lo: u.lo &^ m.lo}
+}
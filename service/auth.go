@@ -0,0 +1,184 @@
+package service
+
+import (
+	"sort"
+
+	"github.com/LOAFLE/util-go/ctx"
+)
+
+const (
+	// PrincipalKey is the ctx.Ctx attribute holding the Principal of a call.
+	PrincipalKey = ctx.CtxKey("service.principal")
+)
+
+// Principal is the identity on whose behalf a method is called.
+type Principal interface {
+	Name() string
+	Roles() []string
+}
+
+type principal struct {
+	name  string
+	roles []string
+}
+
+func (p *principal) Name() string {
+	return p.name
+}
+
+func (p *principal) Roles() []string {
+	return p.roles
+}
+
+// NewPrincipal returns a Principal with a name and roles.
+func NewPrincipal(name string, roles ...string) Principal {
+	return &principal{
+		name:  name,
+		roles: roles,
+	}
+}
+
+// SetPrincipal stores p in c.
+func SetPrincipal(c ctx.Ctx, p Principal) {
+	c.SetAttribute(PrincipalKey, p)
+}
+
+// PrincipalOf returns the Principal stored in c, or nil.
+func PrincipalOf(c ctx.Ctx) Principal {
+	if nil == c {
+		return nil
+	}
+	p, _ := c.GetAttribute(PrincipalKey).(Principal)
+	return p
+}
+
+// Secured is implemented by receivers that declare the roles allowed to call
+// their methods. The key "" holds the roles of the service, other keys are
+// method names.
+type Secured interface {
+	Roles() map[string][]string
+}
+
+// Authorizer is implemented by receivers that decide themselves whether a
+// principal may call one of their methods, given by its registered name.
+// p is nil for anonymous calls.
+type Authorizer interface {
+	Authorize(p Principal, method string) bool
+}
+
+// SetRoles sets the roles allowed to call a service or a method.
+// A principal needs one of the roles of the method or, if the method has
+// none, one of the roles of the service. Without roles, calls are allowed.
+//
+// name is either "Service" or "Service.Method" of a registered service.
+func (r *Registry) SetRoles(name string, roles ...string) error {
+	name, err := r.resolveName(name)
+	if nil != err {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if nil == r.roles {
+		r.roles = make(map[string][]string)
+	}
+	if 0 == len(roles) {
+		delete(r.roles, name)
+	} else {
+		r.roles[name] = append([]string(nil), roles...)
+	}
+	r.publish()
+	return nil
+}
+
+// SetPolicy sets the roles of many services or methods at once.
+func (r *Registry) SetPolicy(policy map[string][]string) error {
+	for name, roles := range policy {
+		if err := r.SetRoles(name, roles...); nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+// Authorize returns ErrForbidden if p may not call method.
+//
+// The method name uses a dotted notation as in "Service.Method".
+func (r *Registry) Authorize(p Principal, method string) error {
+	s, mm, err := r.Get(method)
+	if nil != err {
+		return err
+	}
+	return r.authorize(r.load(), s, mm, p)
+}
+
+// AllowedMethods returns the sorted names of the methods p may call.
+func (r *Registry) AllowedMethods(p Principal) []string {
+	snapshot := r.load()
+	methods := make([]string, 0)
+	for _, s := range snapshot.services {
+		for name, mm := range s.methods {
+			// Aliases are not listed.
+			if name != mm.name {
+				continue
+			}
+			if nil == r.authorize(snapshot, s, mm, p) {
+				methods = append(methods, mm.fullName)
+			}
+		}
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+func (r *Registry) authorize(snapshot *registrySnapshot, s *ServiceMeta, mm *MethodMeta, p Principal) error {
+	roles, ok := snapshot.roles[mm.fullName]
+	if !ok {
+		roles = snapshot.roles[s.name]
+	}
+	if 0 < len(roles) && !hasAnyRole(p, roles) {
+		return newErrForbidden(p, mm.fullName)
+	}
+
+	if nil != s.authorizer && !s.authorizer.Authorize(p, mm.name) {
+		return newErrForbidden(p, mm.fullName)
+	}
+	return nil
+}
+
+func hasAnyRole(p Principal, roles []string) bool {
+	if nil == p {
+		return false
+	}
+	for _, have := range p.Roles() {
+		for _, want := range roles {
+			if have == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// rolesOf returns the roles declared by a Secured receiver by their
+// registered names.
+func rolesOf(s *ServiceMeta) (map[string][]string, error) {
+	secured, ok := s.rcvrV.Interface().(Secured)
+	if !ok {
+		return nil, nil
+	}
+	roles := make(map[string][]string)
+	for mName, rs := range secured.Roles() {
+		if "" != mName {
+			mName = s.methodName(mName)
+		}
+		name, err := s.resolveName(mName)
+		if nil != err {
+			return nil, err
+		}
+		if 0 < len(rs) {
+			roles[name] = append([]string(nil), rs...)
+		}
+	}
+	return roles, nil
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/LOAFLE/util-go/ctx"
+)
+
+type Hosts struct{}
+
+func (h *Hosts) List() error   { return nil }
+func (h *Hosts) Scan() error   { return nil }
+func (h *Hosts) Delete() error { return nil }
+
+func (h *Hosts) Roles() map[string][]string {
+	return map[string][]string{
+		"":       []string{"viewer", "admin"},
+		"Delete": []string{"admin"},
+	}
+}
+
+func (h *Hosts) Authorize(p Principal, method string) bool {
+	return "Scan" != method || (nil != p && "mallory" != p.Name())
+}
+
+func TestRegistryAuthorize(t *testing.T) {
+	r := &Registry{}
+	if err := r.Register(&Hosts{}, ""); nil != err {
+		t.Fatal(err)
+	}
+
+	viewer := NewPrincipal("alice", "viewer")
+	admin := NewPrincipal("bob", "admin")
+	mallory := NewPrincipal("mallory", "admin")
+
+	tests := []struct {
+		p      Principal
+		method string
+		ok     bool
+	}{
+		{nil, "Hosts.List", false},
+		{viewer, "Hosts.List", true},
+		{viewer, "Hosts.Delete", false},
+		{admin, "Hosts.Delete", true},
+		{admin, "Hosts.Scan", true},
+		{mallory, "Hosts.Scan", false},
+	}
+	for _, tt := range tests {
+		c := ctx.NewCtx(nil)
+		if nil != tt.p {
+			SetPrincipal(c, tt.p)
+		}
+		_, err := r.Invoke(c, tt.method, nil)
+		if tt.ok && nil != err {
+			t.Errorf("Invoke(%v, %s) error = %v", tt.p, tt.method, err)
+		}
+		if !tt.ok {
+			if _, ok := err.(ErrForbidden); !ok {
+				t.Errorf("Invoke(%v, %s) error = %v, want ErrForbidden", tt.p, tt.method, err)
+			}
+		}
+	}
+
+	if got, want := r.AllowedMethods(viewer), []string{"Hosts.List", "Hosts.Scan"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("AllowedMethods() = %v, want %v", got, want)
+	}
+
+	if err := r.SetRoles("Hosts.List"); nil != err {
+		t.Fatal(err)
+	}
+	if err := r.SetRoles("Hosts"); nil != err {
+		t.Fatal(err)
+	}
+	if err := r.Authorize(nil, "Hosts.List"); nil != err {
+		t.Errorf("Authorize() without roles error = %v", err)
+	}
+}
+
+type MisconfiguredHosts struct{ Hosts }
+
+func (h *MisconfiguredHosts) Roles() map[string][]string {
+	return map[string][]string{"Reboot": []string{"admin"}}
+}
+
+func TestRegistryRegisterRoles(t *testing.T) {
+	r := &Registry{}
+	if err := r.Register(&MisconfiguredHosts{}, ""); nil == err {
+		t.Errorf("Register() with roles of an unknown method succeeded")
+	}
+	if _, _, err := r.Get("MisconfiguredHosts.List"); nil == err {
+		t.Errorf("service with invalid roles is callable")
+	}
+
+	// A secured service is never callable without its roles.
+	done := make(chan error)
+	go func() {
+		done <- r.Register(&Hosts{}, "")
+	}()
+	for registered := false; !registered; {
+		select {
+		case err := <-done:
+			if nil != err {
+				t.Fatal(err)
+			}
+			registered = true
+		default:
+		}
+		if _, err := r.Invoke(nil, "Hosts.List", nil); nil == err {
+			t.Fatal("Invoke() without roles succeeded")
+		}
+	}
+}
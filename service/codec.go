@@ -0,0 +1,261 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+
+	lujson "github.com/LOAFLE/util-go/encoding/json"
+	"github.com/LOAFLE/util-go/encoding/msgpack"
+)
+
+// Codec decodes the parameters of a call and encodes its result.
+type Codec interface {
+	// ContentType returns the media type of the encoding.
+	ContentType() string
+	// DecodeParams decodes raw into instances as passed to a ParamDecoder.
+	DecodeParams(raw []byte, instances []interface{}) error
+	// Encode returns the encoding of v.
+	Encode(v interface{}) ([]byte, error)
+}
+
+var (
+	// JSONCodec decodes parameters from a JSON array in declaration order
+	// or, for methods taking a single parameter, from a JSON object.
+	JSONCodec Codec = jsonCodec{}
+	// JSONStringArrayCodec decodes parameters from a JSON array of strings,
+	// each holding a parameter as text or as JSON, e.g. ["1", "{\"a\": 1}"].
+	// Results are encoded as JSON.
+	JSONStringArrayCodec Codec = jsonStringArrayCodec{}
+	// MsgpackCodec decodes parameters from a MessagePack array in
+	// declaration order or, for methods taking a single parameter, from a
+	// MessagePack map.
+	MsgpackCodec Codec = msgpackCodec{}
+)
+
+// Codecs is a list of codecs in order of preference.
+type Codecs []Codec
+
+// DefaultCodecs returns the codecs of the package, JSONCodec first.
+func DefaultCodecs() Codecs {
+	return Codecs{JSONCodec, JSONStringArrayCodec, MsgpackCodec}
+}
+
+var mediaTypeAliases = map[string]string{
+	"application/x-msgpack": "application/msgpack",
+	"text/json":             "application/json",
+}
+
+// Find returns the codec of a media type, which may have parameters, or nil.
+func (cs Codecs) Find(contentType string) Codec {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if nil != err {
+		return nil
+	}
+	if alias, ok := mediaTypeAliases[mediaType]; ok {
+		mediaType = alias
+	}
+	for _, c := range cs {
+		if mediaType == c.ContentType() {
+			return c
+		}
+	}
+	return nil
+}
+
+// Negotiate returns the codec decoding the parameters given by the
+// Content-Type of a request and the codec encoding the result given by its
+// Accept header. An empty contentType selects the first codec. The result is
+// encoded like the parameters if accept is empty or names no known codec.
+func (cs Codecs) Negotiate(contentType string, accept string) (decoder Codec, encoder Codec, err error) {
+	if 0 == len(cs) {
+		return nil, nil, fmt.Errorf("Registry: no codecs")
+	}
+
+	if "" == strings.TrimSpace(contentType) {
+		decoder = cs[0]
+	} else if decoder = cs.Find(contentType); nil == decoder {
+		return nil, nil, fmt.Errorf("Registry: unsupported content type %q", contentType)
+	}
+
+	encoder = decoder
+	for _, mediaType := range parseAccept(accept) {
+		if "*/*" == mediaType {
+			break
+		}
+		if c := cs.Find(mediaType); nil != c {
+			encoder = c
+			break
+		}
+	}
+	return decoder, encoder, nil
+}
+
+// parseAccept returns the media types of an Accept header ordered by
+// quality.
+func parseAccept(accept string) []string {
+	type ranged struct {
+		mediaType string
+		q         float64
+	}
+	ranges := make([]ranged, 0)
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if nil != err {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); nil != err {
+				continue
+			}
+		}
+		if 0 < q {
+			ranges = append(ranges, ranged{mediaType: mediaType, q: q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+
+	mediaTypes := make([]string, len(ranges))
+	for indexI, r := range ranges {
+		mediaTypes[indexI] = r.mediaType
+	}
+	return mediaTypes
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string {
+	return "application/json"
+}
+
+func (jsonCodec) DecodeParams(raw []byte, instances []interface{}) error {
+	raw = bytes.TrimSpace(raw)
+	if 0 == len(raw) || bytes.Equal(raw, []byte("null")) {
+		return checkNoParams(instances)
+	}
+
+	switch raw[0] {
+	case '[':
+		var values []json.RawMessage
+		if err := json.Unmarshal(raw, &values); nil != err {
+			return err
+		}
+		if err := checkParamCount(len(values), instances); nil != err {
+			return err
+		}
+		for indexI, value := range values {
+			if err := json.Unmarshal(value, instances[indexI]); nil != err {
+				return fmt.Errorf("Param[%d]: %v", indexI, err)
+			}
+		}
+		return nil
+	case '{':
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &values); nil != err {
+			return err
+		}
+		if err := checkObjectParams(len(values), instances); nil != err || 0 == len(instances) {
+			return err
+		}
+		return json.Unmarshal(raw, instances[0])
+	default:
+		return fmt.Errorf("Params must be a JSON array or object")
+	}
+}
+
+func (jsonCodec) Encode(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+type jsonStringArrayCodec struct{}
+
+func (jsonStringArrayCodec) ContentType() string {
+	return "application/x-json-string-array"
+}
+
+func (jsonStringArrayCodec) DecodeParams(raw []byte, instances []interface{}) error {
+	raw = bytes.TrimSpace(raw)
+	if 0 == len(raw) {
+		return checkNoParams(instances)
+	}
+	return lujson.SetValueWithJSONStringArrayBytes(raw, instances)
+}
+
+func (jsonStringArrayCodec) Encode(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) ContentType() string {
+	return "application/msgpack"
+}
+
+func (msgpackCodec) DecodeParams(raw []byte, instances []interface{}) error {
+	if 0 == len(raw) || (1 == len(raw) && 0xc0 == raw[0]) {
+		return checkNoParams(instances)
+	}
+
+	var values []msgpack.RawMessage
+	if err := msgpack.Unmarshal(raw, &values); nil == err {
+		if err := checkParamCount(len(values), instances); nil != err {
+			return err
+		}
+		for indexI, value := range values {
+			if err := msgpack.Unmarshal(value, instances[indexI]); nil != err {
+				return fmt.Errorf("Param[%d]: %v", indexI, err)
+			}
+		}
+		return nil
+	}
+
+	var object map[string]msgpack.RawMessage
+	if err := msgpack.Unmarshal(raw, &object); nil != err {
+		return fmt.Errorf("Params must be a MessagePack array or map")
+	}
+	if err := checkObjectParams(len(object), instances); nil != err || 0 == len(instances) {
+		return err
+	}
+	return msgpack.Unmarshal(raw, instances[0])
+}
+
+func (msgpackCodec) Encode(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func checkNoParams(instances []interface{}) error {
+	if 0 != len(instances) {
+		return errNoParams
+	}
+	return nil
+}
+
+func checkParamCount(count int, instances []interface{}) error {
+	if count != len(instances) {
+		return fmt.Errorf("Count of params[%d] and method params[%d] is not same", count, len(instances))
+	}
+	return nil
+}
+
+// checkObjectParams checks that an object with count keys can be decoded
+// into instances.
+func checkObjectParams(count int, instances []interface{}) error {
+	switch len(instances) {
+	case 0:
+		if 0 != count {
+			return fmt.Errorf("Method has no params")
+		}
+		return nil
+	case 1:
+		return nil
+	default:
+		return fmt.Errorf("Object is allowed only for a method with one param, but has %d params", len(instances))
+	}
+}
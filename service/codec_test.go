@@ -0,0 +1,135 @@
+package service
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/LOAFLE/util-go/encoding/msgpack"
+)
+
+func TestCodecsNegotiate(t *testing.T) {
+	codecs := DefaultCodecs()
+
+	tests := []struct {
+		name        string
+		contentType string
+		accept      string
+		decoder     Codec
+		encoder     Codec
+		wantErr     bool
+	}{
+		{"default", "", "", JSONCodec, JSONCodec, false},
+		{"params", "application/json; charset=utf-8", "", JSONCodec, JSONCodec, false},
+		{"msgpack", "application/msgpack", "", MsgpackCodec, MsgpackCodec, false},
+		{"alias", "application/x-msgpack", "", MsgpackCodec, MsgpackCodec, false},
+		{"string array", "application/x-json-string-array", "", JSONStringArrayCodec, JSONStringArrayCodec, false},
+		{"accept", "application/json", "application/msgpack", JSONCodec, MsgpackCodec, false},
+		{"accept quality", "application/msgpack", "application/msgpack;q=0.5, application/json", MsgpackCodec, JSONCodec, false},
+		{"accept any", "application/msgpack", "*/*", MsgpackCodec, MsgpackCodec, false},
+		{"accept unknown", "application/json", "text/html", JSONCodec, JSONCodec, false},
+		{"unsupported", "text/xml", "", nil, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoder, encoder, err := codecs.Negotiate(tt.contentType, tt.accept)
+			if (nil != err) != tt.wantErr {
+				t.Fatalf("Negotiate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if decoder != tt.decoder || encoder != tt.encoder {
+				t.Errorf("Negotiate() = %v, %v, want %v, %v", decoder, encoder, tt.decoder, tt.encoder)
+			}
+		})
+	}
+}
+
+func TestCodecDecodeParams(t *testing.T) {
+	mustMsgpack := func(v interface{}) []byte {
+		buf, err := msgpack.Marshal(v)
+		if nil != err {
+			t.Fatal(err)
+		}
+		return buf
+	}
+
+	tests := []struct {
+		name    string
+		codec   Codec
+		raw     []byte
+		want    ArithArgs
+		wantErr bool
+	}{
+		{"json array", JSONCodec, []byte(`[1, 2]`), ArithArgs{A: 1, B: 2}, false},
+		{"json count", JSONCodec, []byte(`[1]`), ArithArgs{}, true},
+		{"string array", JSONStringArrayCodec, []byte(`["1", "2"]`), ArithArgs{A: 1, B: 2}, false},
+		{"msgpack array", MsgpackCodec, mustMsgpack([]int{1, 2}), ArithArgs{A: 1, B: 2}, false},
+		{"msgpack count", MsgpackCodec, mustMsgpack([]int{1, 2, 3}), ArithArgs{}, true},
+		{"msgpack map", MsgpackCodec, mustMsgpack(map[string]int{"a": 1}), ArithArgs{}, true},
+		{"msgpack scalar", MsgpackCodec, mustMsgpack(1), ArithArgs{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got ArithArgs
+			err := tt.codec.DecodeParams(tt.raw, []interface{}{&got.A, &got.B})
+			if (nil != err) != tt.wantErr {
+				t.Fatalf("DecodeParams() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("DecodeParams() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	var args ArithArgs
+	raw := mustMsgpack(map[string]int{"a": 3, "b": 4})
+	if err := MsgpackCodec.DecodeParams(raw, []interface{}{&args}); nil != err {
+		t.Fatal(err)
+	}
+	if (ArithArgs{A: 3, B: 4}) != args {
+		t.Errorf("DecodeParams() = %v", args)
+	}
+}
+
+func TestHTTPHandlerMsgpack(t *testing.T) {
+	r := &Registry{}
+	if err := r.Register(&Arith{}, ""); nil != err {
+		t.Fatal(err)
+	}
+	h := NewHTTPHandler(r)
+
+	body, err := msgpack.Marshal([]int{1, 2})
+	if nil != err {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/Arith/Add", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/msgpack")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if http.StatusOK != w.Code {
+		t.Fatalf("ServeHTTP() code = %d", w.Code)
+	}
+	if got := w.Header().Get("Content-Type"); "application/msgpack" != got {
+		t.Errorf("Content-Type = %q", got)
+	}
+	var envelope struct {
+		Result int `msgpack:"result"`
+	}
+	if err := msgpack.Unmarshal(w.Body.Bytes(), &envelope); nil != err {
+		t.Fatal(err)
+	}
+	if 3 != envelope.Result {
+		t.Errorf("ServeHTTP() result = %d, want 3", envelope.Result)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/Arith/Add", strings.NewReader(`[1, 2]`))
+	req.Header.Set("Content-Type", "text/xml")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if http.StatusUnsupportedMediaType != w.Code {
+		t.Errorf("ServeHTTP() code = %d, want %d", w.Code, http.StatusUnsupportedMediaType)
+	}
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+)
+
+var errNoParams = errors.New("params are required")
+
+// ErrMethodNotFound is returned when a "Service.Method" name can not be
+// resolved to a registered method.
+type ErrMethodNotFound struct {
+	method string
+	reason string
+}
+
+func (e ErrMethodNotFound) Error() string {
+	return fmt.Sprintf("Registry: %s %q", e.reason, e.method)
+}
+
+// Method returns the requested method name.
+func (e ErrMethodNotFound) Method() string {
+	return e.method
+}
+
+// ErrInvalidParams is returned when the parameters of a call can not be
+// decoded into the types of the method.
+type ErrInvalidParams struct {
+	method string
+	err    error
+}
+
+func (e ErrInvalidParams) Error() string {
+	return fmt.Sprintf("Registry: invalid params of %q: %v", e.method, e.err)
+}
+
+// Method returns the requested method name.
+func (e ErrInvalidParams) Method() string {
+	return e.method
+}
+
+// Cause returns the decoding error.
+func (e ErrInvalidParams) Cause() error {
+	return e.err
+}
+
+// ErrLimitExceeded is returned when a call exceeds the Limit of a service or
+// a method.
+type ErrLimitExceeded struct {
+	name   string
+	reason string
+}
+
+func (e ErrLimitExceeded) Error() string {
+	return fmt.Sprintf("Registry: %s of %q", e.reason, e.name)
+}
+
+// Name returns the name of the limited service or method.
+func (e ErrLimitExceeded) Name() string {
+	return e.name
+}
+
+// ErrForbidden is returned when a principal is not allowed to call a method.
+type ErrForbidden struct {
+	method    string
+	principal string
+}
+
+func newErrForbidden(p Principal, method string) ErrForbidden {
+	e := ErrForbidden{method: method}
+	if nil != p {
+		e.principal = p.Name()
+	}
+	return e
+}
+
+func (e ErrForbidden) Error() string {
+	if "" == e.principal {
+		return fmt.Sprintf("Registry: anonymous call of %q is forbidden", e.method)
+	}
+	return fmt.Sprintf("Registry: call of %q by %q is forbidden", e.method, e.principal)
+}
+
+// Method returns the requested method name.
+func (e ErrForbidden) Method() string {
+	return e.method
+}
+
+// Principal returns the name of the principal, or "" for anonymous calls.
+func (e ErrForbidden) Principal() string {
+	return e.principal
+}
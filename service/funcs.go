@@ -0,0 +1,87 @@
+package service
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// RegisterFuncs adds a new service whose methods are plain functions.
+//
+// funcs maps method names to functions, which must have the signature of a
+// registrable method without the receiver, e.g. func(string) (int, error).
+// The method names are Go identifiers and named like those of Register.
+func (r *Registry) RegisterFuncs(name string, funcs map[string]interface{}) error {
+	if "" == name {
+		return fmt.Errorf("Registry: no service name for funcs")
+	}
+	if 0 == len(funcs) {
+		return fmt.Errorf("Registry: %q has no funcs", name)
+	}
+
+	s := &ServiceMeta{
+		name:    name,
+		rcvrV:   reflect.ValueOf(funcs),
+		rcvrT:   reflect.TypeOf(funcs),
+		methods: make(map[string]*MethodMeta),
+		names:   make(map[string]string),
+	}
+	_, methodNaming := r.namings()
+
+	names := make([]string, 0, len(funcs))
+	for mName := range funcs {
+		names = append(names, mName)
+	}
+	sort.Strings(names)
+
+	for index, mName := range names {
+		fn := funcs[mName]
+		fv := reflect.ValueOf(fn)
+		if reflect.Func != fv.Kind() || fv.IsNil() {
+			return fmt.Errorf("Registry: %q of %q is not a func", mName, name)
+		}
+		if !isExported(mName) {
+			return fmt.Errorf("Registry: %q of %q is not exported", mName, name)
+		}
+
+		ft := fv.Type()
+		if ft.IsVariadic() {
+			return fmt.Errorf("Registry: %q of %q must not be variadic", mName, name)
+		}
+		paramTypes, returnType, ok, err := signatureOf(ft, 0)
+		if nil != err {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("Registry: %q of %q has unsuitable return types", mName, name)
+		}
+
+		m := reflect.Method{
+			Name:  mName,
+			Type:  methodTypeOf(s.rcvrT, ft),
+			Index: index,
+		}
+		m.Func = reflect.MakeFunc(m.Type, func(in []reflect.Value) []reflect.Value {
+			return fv.Call(in[1:])
+		})
+		if err = s.addMethod(mName, methodNaming, newMethodMeta(m, paramTypes, returnType, fn)); nil != err {
+			return err
+		}
+	}
+
+	return r.add(s)
+}
+
+// methodTypeOf returns the type of ft as a method expression of rcvrT.
+func methodTypeOf(rcvrT reflect.Type, ft reflect.Type) reflect.Type {
+	in := make([]reflect.Type, 0, ft.NumIn()+1)
+	in = append(in, rcvrT)
+	for indexI := 0; indexI < ft.NumIn(); indexI++ {
+		in = append(in, ft.In(indexI))
+	}
+	out := make([]reflect.Type, 0, ft.NumOut())
+	for indexI := 0; indexI < ft.NumOut(); indexI++ {
+		out = append(out, ft.Out(indexI))
+	}
+	return reflect.FuncOf(in, out, false)
+}
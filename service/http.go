@@ -0,0 +1,228 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/LOAFLE/util-go/ctx"
+)
+
+const (
+	// HTTPHeaderKey is the ctx.Ctx attribute holding the http.Header of the
+	// request that started the call.
+	HTTPHeaderKey = ctx.CtxKey("http.header")
+	// HTTPRemoteAddrKey is the ctx.Ctx attribute holding the remote address
+	// of the request that started the call.
+	HTTPRemoteAddrKey = ctx.CtxKey("http.remoteAddr")
+)
+
+// HTTPHandler is a http.Handler that maps "POST /{Service}/{Method}" to the
+// method "Service.Method" of a Registry.
+//
+// The body holds the parameters in the encoding of a Codec selected by the
+// Content-Type of the request, JSON by default. The response is an envelope,
+// {"result": ...} or {"error": {"code": ..., "message": ...}}, in the
+// encoding selected by the Accept header. A valid traceparent header is
+// stored under TraceParentKey for Trace.
+// Mount it under a prefix with http.StripPrefix.
+type HTTPHandler struct {
+	Registry *Registry
+	// Codecs are the supported encodings, nil means DefaultCodecs.
+	Codecs Codecs
+	// Ctx is the parent of the ctx.Ctx created for every request.
+	Ctx ctx.Ctx
+	// AllowedOrigins lists the origins of cross-origin requests that are
+	// allowed. "*" allows any origin, empty disables CORS.
+	AllowedOrigins []string
+	// AllowedHeaders lists the headers of cross-origin requests that are
+	// allowed. Empty allows the headers requested by the preflight.
+	AllowedHeaders []string
+	// MaxAge is the number of seconds a preflight response may be cached.
+	MaxAge int
+	// MaxBodyBytes limits the size of the request body, 0 means no limit.
+	// Larger bodies are answered with 413.
+	MaxBodyBytes int64
+	// ErrorLog logs the errors of calls which are answered with 500 and a
+	// generic message, so that no internal details reach the client. nil
+	// means the standard logger of package log.
+	ErrorLog *log.Logger
+}
+
+// NewHTTPHandler returns a HTTPHandler for the services of r.
+func NewHTTPHandler(r *Registry) *HTTPHandler {
+	return &HTTPHandler{
+		Registry: r,
+	}
+}
+
+type httpError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (h *HTTPHandler) codecs() Codecs {
+	if nil == h.Codecs {
+		return DefaultCodecs()
+	}
+	return h.Codecs
+}
+
+type httpEnvelope struct {
+	Result interface{} `json:"result,omitempty"`
+	Error  *httpError  `json:"error,omitempty"`
+}
+
+func (h *HTTPHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if !h.handleCORS(w, req) {
+		return
+	}
+
+	decoder, encoder, err := h.codecs().Negotiate(req.Header.Get("Content-Type"), req.Header.Get("Accept"))
+	if nil != err {
+		h.writeError(w, JSONCodec, http.StatusUnsupportedMediaType, err)
+		return
+	}
+
+	if http.MethodPost != req.Method {
+		w.Header().Set("Allow", "POST, OPTIONS")
+		h.writeError(w, encoder, http.StatusMethodNotAllowed, fmt.Errorf("Method %s is not allowed", req.Method))
+		return
+	}
+
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if 2 != len(parts) || "" == parts[0] || "" == parts[1] {
+		h.writeError(w, encoder, http.StatusNotFound, fmt.Errorf("Path %q is not /{Service}/{Method}", req.URL.Path))
+		return
+	}
+	method := parts[0] + "." + parts[1]
+
+	var body io.Reader = req.Body
+	if 0 < h.MaxBodyBytes {
+		// One byte more than allowed tells a body that is too large.
+		body = io.LimitReader(body, h.MaxBodyBytes+1)
+	}
+	raw, err := ioutil.ReadAll(body)
+	if nil != err {
+		h.writeError(w, encoder, http.StatusBadRequest, err)
+		return
+	}
+	if 0 < h.MaxBodyBytes && h.MaxBodyBytes < int64(len(raw)) {
+		h.writeError(w, encoder, http.StatusRequestEntityTooLarge, fmt.Errorf("Request body is larger than %d bytes", h.MaxBodyBytes))
+		return
+	}
+
+	c := ctx.NewCtx(h.Ctx)
+	c.SetAttribute(HTTPHeaderKey, req.Header)
+	c.SetAttribute(HTTPRemoteAddrKey, req.RemoteAddr)
+	c.SetAttribute(ContextKey, req.Context())
+	if parent, err := ParseTraceParent(req.Header.Get(TraceParentHeader)); nil == err {
+		c.SetAttribute(TraceParentKey, parent)
+	}
+
+	result, err := h.Registry.Invoke(c, method, func(instances []interface{}) error {
+		return decoder.DecodeParams(raw, instances)
+	})
+	if nil != err {
+		code := httpStatusOf(err)
+		if http.StatusInternalServerError == code {
+			h.logf("service: call of %s failed: %v", method, err)
+			err = errInternal
+		}
+		h.writeError(w, encoder, code, err)
+		return
+	}
+
+	h.write(w, encoder, http.StatusOK, &httpEnvelope{Result: result})
+}
+
+// handleCORS sets the CORS headers of the response and answers preflight
+// requests. It returns false if the request has been answered.
+func (h *HTTPHandler) handleCORS(w http.ResponseWriter, req *http.Request) bool {
+	origin := req.Header.Get("Origin")
+	if "" == origin || !h.isAllowedOrigin(origin) {
+		if http.MethodOptions == req.Method {
+			w.Header().Set("Allow", "POST, OPTIONS")
+			w.WriteHeader(http.StatusNoContent)
+			return false
+		}
+		return true
+	}
+
+	header := w.Header()
+	header.Add("Vary", "Origin")
+	header.Set("Access-Control-Allow-Origin", origin)
+
+	if http.MethodOptions != req.Method || "" == req.Header.Get("Access-Control-Request-Method") {
+		return true
+	}
+
+	header.Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+	if 0 < len(h.AllowedHeaders) {
+		header.Set("Access-Control-Allow-Headers", strings.Join(h.AllowedHeaders, ", "))
+	} else if requested := req.Header.Get("Access-Control-Request-Headers"); "" != requested {
+		header.Set("Access-Control-Allow-Headers", requested)
+	}
+	if 0 < h.MaxAge {
+		header.Set("Access-Control-Max-Age", strconv.Itoa(h.MaxAge))
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return false
+}
+
+func (h *HTTPHandler) isAllowedOrigin(origin string) bool {
+	for _, allowed := range h.AllowedOrigins {
+		if "*" == allowed || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+var errInternal = errors.New(http.StatusText(http.StatusInternalServerError))
+
+func (h *HTTPHandler) logf(format string, args ...interface{}) {
+	if nil != h.ErrorLog {
+		h.ErrorLog.Printf(format, args...)
+	} else {
+		log.Printf(format, args...)
+	}
+}
+
+func (h *HTTPHandler) writeError(w http.ResponseWriter, encoder Codec, code int, err error) {
+	h.write(w, encoder, code, &httpEnvelope{Error: &httpError{Code: code, Message: err.Error()}})
+}
+
+func (h *HTTPHandler) write(w http.ResponseWriter, encoder Codec, code int, envelope *httpEnvelope) {
+	buf, err := encoder.Encode(envelope)
+	if nil != err {
+		h.logf("service: encoding of the response failed: %v", err)
+		code = http.StatusInternalServerError
+		buf, _ = encoder.Encode(&httpEnvelope{Error: &httpError{Code: code, Message: errInternal.Error()}})
+	}
+	w.Header().Set("Content-Type", encoder.ContentType())
+	w.WriteHeader(code)
+	w.Write(buf)
+}
+
+// httpStatusOf returns the HTTP status code of an error returned by
+// Registry.Invoke.
+func httpStatusOf(err error) int {
+	switch err.(type) {
+	case ErrMethodNotFound:
+		return http.StatusNotFound
+	case ErrInvalidParams:
+		return http.StatusBadRequest
+	case ErrForbidden:
+		return http.StatusForbidden
+	case ErrLimitExceeded:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/LOAFLE/util-go/ctx"
+)
+
+type Arith struct{}
+
+type ArithArgs struct {
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+func (a *Arith) Add(x int, y int) (int, error) {
+	return x + y, nil
+}
+
+func (a *Arith) Sum(args *ArithArgs) (int, error) {
+	return args.A + args.B, nil
+}
+
+func (a *Arith) Div(x int, y int) (int, error) {
+	if 0 == y {
+		return 0, errors.New("divide by zero")
+	}
+	return x / y, nil
+}
+
+func (a *Arith) Agent(c ctx.Ctx) (string, error) {
+	return c.GetAttribute(HTTPHeaderKey).(http.Header).Get("User-Agent"), nil
+}
+
+func TestHTTPHandler(t *testing.T) {
+	r := &Registry{}
+	if err := r.Register(&Arith{}, ""); nil != err {
+		t.Fatal(err)
+	}
+	var errorLog bytes.Buffer
+	h := NewHTTPHandler(r)
+	h.AllowedOrigins = []string{"http://example.com"}
+	h.ErrorLog = log.New(&errorLog, "", 0)
+	h.MaxBodyBytes = 64
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		code   int
+		want   string
+	}{
+		{"array", http.MethodPost, "/Arith/Add", `[1, 2]`, http.StatusOK, `{"result":3}`},
+		{"object", http.MethodPost, "/Arith/Sum", `{"a": 3, "b": 4}`, http.StatusOK, `{"result":7}`},
+		{"ctx", http.MethodPost, "/Arith/Agent", ``, http.StatusOK, `{"result":"test"}`},
+		{"method error", http.MethodPost, "/Arith/Div", `[1, 0]`, http.StatusInternalServerError, `{"error":{"code":500,"message":"Internal Server Error"}}`},
+		{"bad params", http.MethodPost, "/Arith/Add", `[1]`, http.StatusBadRequest, ``},
+		{"object for many params", http.MethodPost, "/Arith/Add", `{"a": 1}`, http.StatusBadRequest, ``},
+		{"unknown method", http.MethodPost, "/Arith/Mul", `[1, 2]`, http.StatusNotFound, ``},
+		{"bad path", http.MethodPost, "/Arith", `[]`, http.StatusNotFound, ``},
+		{"not post", http.MethodGet, "/Arith/Add", ``, http.StatusMethodNotAllowed, ``},
+		{"at limit", http.MethodPost, "/Arith/Add", `[1, ` + strings.Repeat(" ", 58) + `2]`, http.StatusOK, `{"result":3}`},
+		{"too large", http.MethodPost, "/Arith/Add", `[1, ` + strings.Repeat(" ", 64) + `2]`, http.StatusRequestEntityTooLarge, ``},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("User-Agent", "test")
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != tt.code {
+				t.Errorf("ServeHTTP() code = %d, want %d, body = %s", w.Code, tt.code, w.Body.String())
+			}
+			if "" != tt.want && strings.TrimSpace(w.Body.String()) != tt.want {
+				t.Errorf("ServeHTTP() body = %s, want %s", w.Body.String(), tt.want)
+			}
+			var envelope map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &envelope); nil != err {
+				t.Errorf("ServeHTTP() body is not JSON: %v", err)
+			}
+		})
+	}
+
+	if !strings.Contains(errorLog.String(), "divide by zero") {
+		t.Errorf("ErrorLog = %q, want the error of the method", errorLog.String())
+	}
+}
+
+func TestHTTPHandlerCORS(t *testing.T) {
+	r := &Registry{}
+	if err := r.Register(&Arith{}, ""); nil != err {
+		t.Fatal(err)
+	}
+	h := NewHTTPHandler(r)
+	h.AllowedOrigins = []string{"http://example.com"}
+
+	req := httptest.NewRequest(http.MethodOptions, "/Arith/Add", nil)
+	req.Header.Set("Origin", "http://example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	req.Header.Set("Access-Control-Request-Headers", "Content-Type")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("preflight code = %d, want %d", w.Code, http.StatusNoContent)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); "http://example.com" != got {
+		t.Errorf("Access-Control-Allow-Origin = %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Headers"); "Content-Type" != got {
+		t.Errorf("Access-Control-Allow-Headers = %q", got)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/Arith/Add", strings.NewReader(`[1, 2]`))
+	req.Header.Set("Origin", "http://evil.com")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if got := w.Header().Get("Access-Control-Allow-Origin"); "" != got {
+		t.Errorf("Access-Control-Allow-Origin = %q, want none", got)
+	}
+}
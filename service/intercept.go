@@ -0,0 +1,45 @@
+package service
+
+import (
+	"github.com/LOAFLE/util-go/ctx"
+)
+
+// Invocation is a call of a method passed through the interceptors.
+type Invocation struct {
+	Ctx ctx.Ctx
+	// Method is the name of the method as in "Service.Method".
+	Method string
+	// Params are the decoded parameters, pointers as passed to the
+	// ParamDecoder.
+	Params []interface{}
+}
+
+// InvocationHandler calls the method of an Invocation.
+type InvocationHandler func(inv *Invocation) (interface{}, error)
+
+// Interceptor is called around the method of every Invoke.
+// It calls next to proceed with the call, or returns without calling it.
+type Interceptor func(inv *Invocation, next InvocationHandler) (interface{}, error)
+
+// Intercept appends interceptors, which are called after the parameters of
+// a call have been decoded. The interceptor added first is the outermost.
+func (r *Registry) Intercept(interceptors ...Interceptor) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	all := make([]Interceptor, 0, len(r.interceptors)+len(interceptors))
+	all = append(all, r.interceptors...)
+	all = append(all, interceptors...)
+	r.interceptors = all
+	r.publish()
+}
+
+func chainInterceptors(interceptors []Interceptor, handler InvocationHandler) InvocationHandler {
+	for indexI := len(interceptors) - 1; 0 <= indexI; indexI-- {
+		interceptor, next := interceptors[indexI], handler
+		handler = func(inv *Invocation) (interface{}, error) {
+			return interceptor(inv, next)
+		}
+	}
+	return handler
+}
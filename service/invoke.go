@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/LOAFLE/util-go/ctx"
+)
+
+// ContextKey is the ctx.Ctx attribute holding the context.Context of the
+// call, set by transports. Calls waiting for a Limit give up when it is
+// done.
+const ContextKey = ctx.CtxKey("service.context")
+
+var (
+	typeOfCtx = reflect.TypeOf((*ctx.Ctx)(nil)).Elem()
+)
+
+// ContextOf returns the context.Context of the call stored under
+// ContextKey, or context.Background().
+func ContextOf(c ctx.Ctx) context.Context {
+	if nil != c {
+		if cc, ok := c.GetAttribute(ContextKey).(context.Context); ok && nil != cc {
+			return cc
+		}
+	}
+	return context.Background()
+}
+
+// ParamDecoder fills the parameters of a call.
+// instances are pointers to the zero values of the method parameters in
+// declaration order. Parameters of type ctx.Ctx are supplied by the registry
+// and are not part of instances.
+type ParamDecoder func(instances []interface{}) error
+
+// Invoke resolves method, decodes its parameters with decode and calls it.
+//
+// The method name uses a dotted notation as in "Service.Method".
+// c is passed to the parameters of type ctx.Ctx. decode may be nil for
+// methods without parameters.
+func (r *Registry) Invoke(c ctx.Ctx, method string, decode ParamDecoder) (interface{}, error) {
+	exporters := r.load().exporters
+	if 0 == len(exporters) {
+		return r.invoke(c, method, decode)
+	}
+
+	span, c := startSpan(c, method)
+	result, err := r.invoke(c, method, decode)
+	span.finish(err, exporters)
+	return result, err
+}
+
+func (r *Registry) invoke(c ctx.Ctx, method string, decode ParamDecoder) (interface{}, error) {
+	s, mm, err := r.Get(method)
+	if nil != err {
+		return nil, err
+	}
+
+	if err = r.authorize(r.load(), s, mm, PrincipalOf(c)); nil != err {
+		return nil, err
+	}
+
+	// Aliases share the limits of the method.
+	method = mm.fullName
+	release, err := r.acquireLimits(c, s.name, method)
+	if nil != err {
+		return nil, err
+	}
+	if nil != release {
+		defer release()
+	}
+
+	instances, err := mm.decode(method, decode)
+	if nil != err {
+		return nil, err
+	}
+
+	interceptors := r.load().interceptors
+	if 0 == len(interceptors) {
+		return mm.call(s.rcvrV, c, instances)
+	}
+
+	if nil == c {
+		c = ctx.NewCtx(nil)
+	}
+	inv := &Invocation{
+		Ctx:    c,
+		Method: method,
+		Params: instances,
+	}
+	return chainInterceptors(interceptors, func(inv *Invocation) (interface{}, error) {
+		return mm.call(s.rcvrV, inv.Ctx, inv.Params)
+	})(inv)
+}
+
+// ParamCount returns the number of parameters that have to be decoded,
+// which excludes parameters of type ctx.Ctx.
+func (mm *MethodMeta) ParamCount() int {
+	return mm.decodeCount
+}
+
+// paramMeta caches what is needed to allocate an argument of a method.
+type paramMeta struct {
+	t     reflect.Type
+	isPtr bool
+	isCtx bool
+	alloc func() interface{} // returns a pointer to a new zero value
+}
+
+// newMethodMeta returns the MethodMeta of m. fns are method values of m
+// bound to the receiver, the first one with a typed invoker is used.
+func newMethodMeta(m reflect.Method, paramTypes []reflect.Type, returnType reflect.Type, fns ...interface{}) *MethodMeta {
+	mm := &MethodMeta{
+		method:     m,
+		paramTypes: paramTypes,
+		returnType: returnType,
+		params:     make([]paramMeta, len(paramTypes)),
+	}
+	for indexI, pt := range paramTypes {
+		mm.params[indexI] = paramMeta{
+			t:     pt,
+			isPtr: reflect.Ptr == pt.Kind(),
+			isCtx: typeOfCtx == pt,
+			alloc: allocatorOf(pt),
+		}
+		if mm.params[indexI].isCtx {
+			mm.hasCtx = true
+		} else {
+			mm.decodeCount++
+		}
+	}
+	for _, fn := range fns {
+		if nil == fn {
+			continue
+		}
+		if mm.invoker = bindTypedInvoker(fn); nil != mm.invoker {
+			break
+		}
+	}
+	return mm
+}
+
+// ctxValue returns c as a reflect.Value of type ctx.Ctx, also if c is nil.
+func ctxValue(c ctx.Ctx) reflect.Value {
+	v := reflect.New(typeOfCtx).Elem()
+	if nil != c {
+		v.Set(reflect.ValueOf(c))
+	}
+	return v
+}
+
+// decode allocates the parameters of the method and fills them with decode.
+func (mm *MethodMeta) decode(method string, decode ParamDecoder) ([]interface{}, error) {
+	var instances []interface{}
+	if 0 < mm.decodeCount {
+		instances = make([]interface{}, 0, mm.decodeCount)
+		for indexI := range mm.params {
+			if !mm.params[indexI].isCtx {
+				instances = append(instances, mm.params[indexI].alloc())
+			}
+		}
+	}
+
+	if nil != decode {
+		if err := decode(instances); nil != err {
+			return nil, ErrInvalidParams{method: method, err: err}
+		}
+	} else if 0 < len(instances) {
+		return nil, ErrInvalidParams{method: method, err: errNoParams}
+	}
+	return instances, nil
+}
+
+// call calls the method with the decoded parameters.
+func (mm *MethodMeta) call(rcvr reflect.Value, c ctx.Ctx, instances []interface{}) (interface{}, error) {
+	if nil == c && mm.hasCtx {
+		c = ctx.NewCtx(nil)
+	}
+
+	if nil != mm.invoker {
+		return mm.invoker(c, instances)
+	}
+
+	in := make([]reflect.Value, len(mm.params)+1)
+	in[0] = rcvr
+	indexJ := 0
+	for indexI := range mm.params {
+		p := &mm.params[indexI]
+		if p.isCtx {
+			in[indexI+1] = ctxValue(c)
+			continue
+		}
+		v := reflect.ValueOf(instances[indexJ])
+		indexJ++
+		if !p.isPtr {
+			v = v.Elem()
+		}
+		in[indexI+1] = v
+	}
+
+	out := mm.Call(in)
+
+	var result interface{}
+	if nil != mm.returnType {
+		result = out[0].Interface()
+	}
+	if errV := out[len(out)-1]; !errV.IsNil() {
+		return result, errV.Interface().(error)
+	}
+	return result, nil
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"reflect"
+
+	"github.com/LOAFLE/util-go/ctx"
+)
+
+// Binder is implemented by receivers that hand out their methods as bound
+// method values, e.g. map[string]interface{}{"Echo": e.Echo}.
+// Methods of the common signatures handled by the registry are then called
+// without any reflection.
+type Binder interface {
+	BoundMethods() map[string]interface{}
+}
+
+// typedInvoker calls a method bound to its receiver without reflection.
+// instances are the decoded arguments as allocated by paramMeta.alloc.
+type typedInvoker func(c ctx.Ctx, instances []interface{}) (interface{}, error)
+
+// allocatorOf returns a function allocating the argument of type t.
+// Common types are allocated without reflection.
+func allocatorOf(t reflect.Type) func() interface{} {
+	if reflect.Ptr == t.Kind() {
+		t = t.Elem()
+	}
+
+	switch t {
+	case typeOfString:
+		return func() interface{} { return new(string) }
+	case typeOfInt:
+		return func() interface{} { return new(int) }
+	case typeOfInt64:
+		return func() interface{} { return new(int64) }
+	case typeOfBool:
+		return func() interface{} { return new(bool) }
+	case typeOfFloat64:
+		return func() interface{} { return new(float64) }
+	case typeOfStrings:
+		return func() interface{} { return new([]string) }
+	case typeOfMap:
+		return func() interface{} { return new(map[string]interface{}) }
+	}
+	return func() interface{} {
+		return reflect.New(t).Interface()
+	}
+}
+
+var (
+	typeOfString  = reflect.TypeOf("")
+	typeOfInt     = reflect.TypeOf(int(0))
+	typeOfInt64   = reflect.TypeOf(int64(0))
+	typeOfBool    = reflect.TypeOf(false)
+	typeOfFloat64 = reflect.TypeOf(float64(0))
+	typeOfStrings = reflect.TypeOf([]string(nil))
+	typeOfMap     = reflect.TypeOf(map[string]interface{}(nil))
+)
+
+// bindTypedInvoker returns a typedInvoker for fn, a method value, if its
+// signature is one of the common ones, or nil otherwise.
+// Only method values of a Binder are called without reflection. Method
+// values obtained through reflect still go through reflect when they are
+// called, they only save building the []reflect.Value of the arguments,
+// see BenchmarkRegistryInvokeEcho and BenchmarkRegistryInvokeEchoReflect.
+func bindTypedInvoker(fn interface{}) typedInvoker {
+	switch f := fn.(type) {
+	case func() error:
+		return func(c ctx.Ctx, in []interface{}) (interface{}, error) {
+			return nil, f()
+		}
+	case func() (string, error):
+		return func(c ctx.Ctx, in []interface{}) (interface{}, error) {
+			return f()
+		}
+	case func() (interface{}, error):
+		return func(c ctx.Ctx, in []interface{}) (interface{}, error) {
+			return f()
+		}
+	case func(string) error:
+		return func(c ctx.Ctx, in []interface{}) (interface{}, error) {
+			return nil, f(*in[0].(*string))
+		}
+	case func(string) (string, error):
+		return func(c ctx.Ctx, in []interface{}) (interface{}, error) {
+			return f(*in[0].(*string))
+		}
+	case func(string) (interface{}, error):
+		return func(c ctx.Ctx, in []interface{}) (interface{}, error) {
+			return f(*in[0].(*string))
+		}
+	case func(int) (int, error):
+		return func(c ctx.Ctx, in []interface{}) (interface{}, error) {
+			return f(*in[0].(*int))
+		}
+	case func(ctx.Ctx) error:
+		return func(c ctx.Ctx, in []interface{}) (interface{}, error) {
+			return nil, f(c)
+		}
+	case func(ctx.Ctx) (interface{}, error):
+		return func(c ctx.Ctx, in []interface{}) (interface{}, error) {
+			return f(c)
+		}
+	case func(ctx.Ctx, string) error:
+		return func(c ctx.Ctx, in []interface{}) (interface{}, error) {
+			return nil, f(c, *in[0].(*string))
+		}
+	case func(ctx.Ctx, string) (string, error):
+		return func(c ctx.Ctx, in []interface{}) (interface{}, error) {
+			return f(c, *in[0].(*string))
+		}
+	case func(ctx.Ctx, string) (interface{}, error):
+		return func(c ctx.Ctx, in []interface{}) (interface{}, error) {
+			return f(c, *in[0].(*string))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Initializer is implemented by receivers that have to be initialized
+// before any service is started.
+//
+// Init, Start and Stop are also registered as methods of the service, like
+// any other exported method. A receiver which must not expose them excludes
+// them with Excluder.
+type Initializer interface {
+	Init() error
+}
+
+// Starter is implemented by receivers that have to be started.
+type Starter interface {
+	Start() error
+}
+
+// Stopper is implemented by receivers that have to be stopped.
+// Stop must return eventually: if it takes longer than the timeout of
+// StopAll, the goroutine calling it is abandoned and keeps running until
+// Stop returns, concurrently with the Stop of the following services.
+type Stopper interface {
+	Stop() error
+}
+
+// Dependent is implemented by receivers that depend on other services.
+// Dependencies returns the names of those services, which are started
+// before and stopped after the receiver.
+type Dependent interface {
+	Dependencies() []string
+}
+
+// StartOrder returns the names of the registered services in the order in
+// which they are started. Services come after their dependencies, which are
+// those given by Dependent and those injected into their fields.
+func (r *Registry) StartOrder() ([]string, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.startOrder()
+}
+
+// startOrder returns the names of the registered services in start order.
+// The caller must hold the lock of mutex.
+func (r *Registry) startOrder() ([]string, error) {
+	names := make([]string, 0, len(r.services))
+	for name := range r.services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(names))
+	order := make([]string, 0, len(names))
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("Registry: circular dependency: %s", strings.Join(append(path, name), " -> "))
+		}
+		state[name] = visiting
+		path = append(path, name)
+
+		for _, dep := range r.services[name].dependencies() {
+			if _, ok := r.services[dep]; !ok {
+				return fmt.Errorf("Registry: service %q depends on unknown service %q", name, dep)
+			}
+			if err := visit(dep, path); nil != err {
+				return err
+			}
+		}
+
+		state[name] = visited
+		order = append(order, name)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name, nil); nil != err {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// dependencies returns the names of the services s depends on.
+func (s *ServiceMeta) dependencies() []string {
+	var deps []string
+	if dependent, ok := s.rcvrV.Interface().(Dependent); ok {
+		deps = append(deps, dependent.Dependencies()...)
+	}
+	for _, inj := range s.injections {
+		deps = append(deps, inj.service)
+	}
+	return deps
+}
+
+// DefaultStopTimeout is the time each Stop may take when StartAll stops the
+// services started so far, unless SetStopTimeout sets another one.
+const DefaultStopTimeout = 30 * time.Second
+
+// SetStopTimeout sets the time each Stop may take when StartAll stops the
+// services started so far after a Start failed. 0 means
+// DefaultStopTimeout.
+func (r *Registry) SetStopTimeout(timeout time.Duration) {
+	r.lifecycleMutex.Lock()
+	defer r.lifecycleMutex.Unlock()
+	r.stopTimeout = timeout
+}
+
+// StartAll wires, initializes and then starts all registered services in
+// dependency order. If a service fails, the services started so far are
+// stopped in reverse order, each within the timeout of SetStopTimeout, and
+// the error is returned with those of the stops.
+func (r *Registry) StartAll() error {
+	r.lifecycleMutex.Lock()
+	defer r.lifecycleMutex.Unlock()
+
+	if 0 < len(r.started) {
+		return fmt.Errorf("Registry: services are already started")
+	}
+
+	if err := r.Wire(); nil != err {
+		return err
+	}
+	order, err := r.StartOrder()
+	if nil != err {
+		return err
+	}
+	services := r.servicesOf(order)
+
+	for _, s := range services {
+		if initializer, ok := s.rcvrV.Interface().(Initializer); ok {
+			if err := initializer.Init(); nil != err {
+				return fmt.Errorf("Registry: init of service %q failed: %v", s.name, err)
+			}
+		}
+	}
+
+	for _, s := range services {
+		if starter, ok := s.rcvrV.Interface().(Starter); ok {
+			if err := starter.Start(); nil != err {
+				timeout := r.stopTimeout
+				if 0 >= timeout {
+					timeout = DefaultStopTimeout
+				}
+				if stopErr := r.stopStarted(timeout); nil != stopErr {
+					return fmt.Errorf("Registry: start of service %q failed: %v; %v", s.name, err, stopErr)
+				}
+				return fmt.Errorf("Registry: start of service %q failed: %v", s.name, err)
+			}
+		}
+		r.started = append(r.started, s)
+	}
+	return nil
+}
+
+// StopAll stops the started services in reverse dependency order.
+// Each Stop may take at most timeout, 0 means no timeout; a service that
+// does not stop in time is reported and skipped while its Stop keeps
+// running, see Stopper.
+func (r *Registry) StopAll(timeout time.Duration) error {
+	r.lifecycleMutex.Lock()
+	defer r.lifecycleMutex.Unlock()
+
+	return r.stopStarted(timeout)
+}
+
+func (r *Registry) stopStarted(timeout time.Duration) error {
+	var errs []string
+	for indexI := len(r.started) - 1; 0 <= indexI; indexI-- {
+		s := r.started[indexI]
+		stopper, ok := s.rcvrV.Interface().(Stopper)
+		if !ok {
+			continue
+		}
+		if err := stopWithTimeout(stopper, timeout); nil != err {
+			errs = append(errs, fmt.Sprintf("%q: %v", s.name, err))
+		}
+	}
+	r.started = nil
+
+	if 0 < len(errs) {
+		return fmt.Errorf("Registry: stop of services failed: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func stopWithTimeout(stopper Stopper, timeout time.Duration) error {
+	if 0 >= timeout {
+		return stopper.Stop()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- stopper.Stop()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		return fmt.Errorf("timed out after %v, Stop is still running", timeout)
+	}
+}
+
+func (r *Registry) servicesOf(names []string) []*ServiceMeta {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	services := make([]*ServiceMeta, 0, len(names))
+	for _, name := range names {
+		services = append(services, r.services[name])
+	}
+	return services
+}
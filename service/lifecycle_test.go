@@ -0,0 +1,218 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lifecycleLog is written by the Stop of a service which timed out while
+// the following services are stopped.
+type lifecycleLog struct {
+	mutex  sync.Mutex
+	events []string
+}
+
+func (l *lifecycleLog) add(event string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.events = append(l.events, event)
+}
+
+func (l *lifecycleLog) get() []string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return append([]string(nil), l.events...)
+}
+
+type Store struct {
+	log *lifecycleLog
+}
+
+func (s *Store) Init() error  { s.log.add("init Store"); return nil }
+func (s *Store) Start() error { s.log.add("start Store"); return nil }
+func (s *Store) Stop() error  { s.log.add("stop Store"); return nil }
+func (s *Store) Get() error   { return nil }
+
+type Scanner struct {
+	log  *lifecycleLog
+	fail bool
+}
+
+func (s *Scanner) Dependencies() []string { return []string{"Store"} }
+func (s *Scanner) Start() error {
+	if s.fail {
+		return errors.New("fail")
+	}
+	s.log.add("start Scanner")
+	return nil
+}
+func (s *Scanner) Stop() error {
+	time.Sleep(50 * time.Millisecond)
+	s.log.add("stop Scanner")
+	return nil
+}
+func (s *Scanner) Scan() error { return nil }
+
+func TestRegistryStartAll(t *testing.T) {
+	log := &lifecycleLog{}
+	r := &Registry{}
+	if err := r.Register(&Scanner{log: log}, ""); nil != err {
+		t.Fatal(err)
+	}
+	if err := r.Register(&Store{log: log}, ""); nil != err {
+		t.Fatal(err)
+	}
+	if _, _, err := r.Get("Store.Start"); nil != err {
+		t.Errorf("lifecycle method is not registered as service method: %v", err)
+	}
+
+	if err := r.StartAll(); nil != err {
+		t.Fatal(err)
+	}
+	if err := r.StopAll(time.Second); nil != err {
+		t.Fatal(err)
+	}
+	want := []string{"init Store", "start Store", "start Scanner", "stop Scanner", "stop Store"}
+	if events := log.get(); !reflect.DeepEqual(events, want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+
+	if err := r.StartAll(); nil != err {
+		t.Fatal(err)
+	}
+	if err := r.StopAll(time.Millisecond); nil == err {
+		t.Errorf("StopAll() did not time out")
+	}
+}
+
+func TestRegistryStartAllFailure(t *testing.T) {
+	log := &lifecycleLog{}
+	r := &Registry{}
+	r.Register(&Scanner{log: log, fail: true}, "")
+	r.Register(&Store{log: log}, "")
+
+	if err := r.StartAll(); nil == err {
+		t.Fatal("StartAll() succeeded")
+	}
+	want := []string{"init Store", "start Store", "stop Store"}
+	if events := log.get(); !reflect.DeepEqual(events, want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+}
+
+type Blocking struct {
+	release chan struct{}
+	stopped chan struct{}
+}
+
+func (b *Blocking) Stop() error {
+	<-b.release
+	close(b.stopped)
+	return nil
+}
+func (b *Blocking) Run() error { return nil }
+
+func TestRegistryStopAllTimeout(t *testing.T) {
+	blocking := &Blocking{release: make(chan struct{}), stopped: make(chan struct{})}
+	r := &Registry{}
+	r.Register(blocking, "")
+	if err := r.StartAll(); nil != err {
+		t.Fatal(err)
+	}
+
+	err := r.StopAll(10 * time.Millisecond)
+	if nil == err || !strings.Contains(err.Error(), "still running") {
+		t.Fatalf("StopAll() = %v", err)
+	}
+	// The abandoned Stop ends when it returns.
+	close(blocking.release)
+	select {
+	case <-blocking.stopped:
+	case <-time.After(time.Second):
+		t.Errorf("Stop did not return")
+	}
+}
+
+type Failing struct{}
+
+func (f *Failing) Dependencies() []string { return []string{"Blocking"} }
+func (f *Failing) Start() error           { return errors.New("fail") }
+
+func TestRegistryStartAllRollbackTimeout(t *testing.T) {
+	blocking := &Blocking{release: make(chan struct{}), stopped: make(chan struct{})}
+	defer close(blocking.release)
+	r := &Registry{}
+	r.Register(blocking, "")
+	r.Register(&Failing{}, "")
+	r.SetStopTimeout(10 * time.Millisecond)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- r.StartAll()
+	}()
+	select {
+	case err := <-done:
+		if nil == err || !strings.Contains(err.Error(), "fail") || !strings.Contains(err.Error(), "still running") {
+			t.Errorf("StartAll() = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("StartAll() hangs in a Stop")
+	}
+}
+
+type Daemon struct{}
+
+func (d *Daemon) Start() error { return nil }
+func (d *Daemon) Stop() error  { return nil }
+
+type QuietDaemon struct{ Daemon }
+
+func (d *QuietDaemon) Status() error             { return nil }
+func (d *QuietDaemon) ExcludedMethods() []string { return []string{"Start", "Stop"} }
+
+func TestRegistryLifecycleMethods(t *testing.T) {
+	r := &Registry{}
+	if err := r.Register(&Daemon{}, ""); nil != err {
+		t.Fatalf("Register() of Start and Stop RPCs = %v", err)
+	}
+	if _, _, err := r.Get("Daemon.Stop"); nil != err {
+		t.Errorf("Get(Daemon.Stop) = %v", err)
+	}
+
+	if err := r.Register(&QuietDaemon{}, ""); nil != err {
+		t.Fatal(err)
+	}
+	if _, _, err := r.Get("QuietDaemon.Start"); nil == err {
+		t.Errorf("excluded lifecycle method is registered")
+	}
+	if err := r.StartAll(); nil != err {
+		t.Fatal(err)
+	}
+	if err := r.StopAll(time.Second); nil != err {
+		t.Fatal(err)
+	}
+}
+
+type Cyclic struct{ deps []string }
+
+func (c *Cyclic) Dependencies() []string { return c.deps }
+func (c *Cyclic) Run() error             { return nil }
+
+func TestRegistryStartOrderErrors(t *testing.T) {
+	r := &Registry{}
+	r.Register(&Cyclic{deps: []string{"B"}}, "A")
+	r.Register(&Cyclic{deps: []string{"A"}}, "B")
+	if _, err := r.StartOrder(); nil == err {
+		t.Errorf("StartOrder() did not detect the cycle")
+	}
+
+	r = &Registry{}
+	r.Register(&Cyclic{deps: []string{"C"}}, "A")
+	if _, err := r.StartOrder(); nil == err {
+		t.Errorf("StartOrder() did not detect the missing dependency")
+	}
+}
@@ -0,0 +1,424 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LOAFLE/util-go/ctx"
+)
+
+// LimitPolicy decides what happens to a call that exceeds a Limit.
+type LimitPolicy int
+
+const (
+	// LimitReject rejects the call with ErrLimitExceeded.
+	LimitReject LimitPolicy = iota
+	// LimitWait makes the call wait for a free slot or token.
+	LimitWait
+)
+
+// Limit declares the concurrency and rate limits of a service or a method.
+type Limit struct {
+	// MaxInFlight is the maximum number of concurrent calls, 0 means no limit.
+	MaxInFlight int
+	// QueueLength is the maximum number of calls waiting for a free slot or
+	// token under LimitWait, 0 means no limit.
+	QueueLength int
+	// Rate is the number of calls allowed per second, 0 means no limit.
+	Rate float64
+	// Burst is the size of the token bucket, at least 1.
+	Burst int
+	// Policy decides whether calls over the limit are rejected or wait.
+	Policy LimitPolicy
+	// Timeout is the maximum wait under LimitWait, 0 means no timeout.
+	Timeout time.Duration
+}
+
+// Limited is implemented by receivers that declare their own limits.
+// The key "" is the limit of the service, other keys are method names.
+type Limited interface {
+	Limits() map[string]Limit
+}
+
+// LimiterStats is a snapshot of the utilization of a Limiter.
+type LimiterStats struct {
+	InFlight    int
+	MaxInFlight int
+	Queued      int
+	QueueLength int
+	Tokens      float64
+	Rate        float64
+	Accepted    uint64
+	Rejected    uint64
+}
+
+// Utilization returns the ratio of in-flight calls to MaxInFlight,
+// or 0 if the concurrency is not limited.
+func (s LimiterStats) Utilization() float64 {
+	if 0 >= s.MaxInFlight {
+		return 0
+	}
+	return float64(s.InFlight) / float64(s.MaxInFlight)
+}
+
+// Limiter enforces a Limit.
+type Limiter struct {
+	limit Limit
+	slots chan struct{}
+
+	mtx      sync.Mutex
+	tokens   float64
+	last     time.Time
+	queued   int
+	accepted uint64
+	rejected uint64
+}
+
+// NewLimiter returns a Limiter enforcing l.
+func NewLimiter(l Limit) *Limiter {
+	if 1 > l.Burst {
+		l.Burst = 1
+	}
+	lt := &Limiter{
+		limit:  l,
+		tokens: float64(l.Burst),
+		last:   time.Now(),
+	}
+	if 0 < l.MaxInFlight {
+		lt.slots = make(chan struct{}, l.MaxInFlight)
+	}
+	return lt
+}
+
+// Limit returns the Limit enforced by the Limiter.
+func (lt *Limiter) Limit() Limit {
+	return lt.limit
+}
+
+// Acquire takes a token and a slot for a call.
+// release must be called when the call returns.
+func (lt *Limiter) Acquire(name string) (release func(), err error) {
+	return lt.AcquireContext(context.Background(), name)
+}
+
+// AcquireContext takes a token and a slot for a call like Acquire. A wait
+// under LimitWait also ends when c is done.
+func (lt *Limiter) AcquireContext(c context.Context, name string) (release func(), err error) {
+	var deadline <-chan time.Time
+	if 0 < lt.limit.Timeout {
+		timer := time.NewTimer(lt.limit.Timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	if err = lt.takeToken(c, name, deadline); nil != err {
+		return nil, err
+	}
+	if err = lt.takeSlot(c, name, deadline); nil != err {
+		if 0 < lt.limit.Rate {
+			lt.mtx.Lock()
+			lt.refund()
+			lt.mtx.Unlock()
+		}
+		return nil, err
+	}
+
+	lt.mtx.Lock()
+	lt.accepted++
+	lt.mtx.Unlock()
+
+	if nil == lt.slots {
+		return func() {}, nil
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() { <-lt.slots })
+	}, nil
+}
+
+// Stats returns the current utilization of the Limiter.
+func (lt *Limiter) Stats() LimiterStats {
+	lt.mtx.Lock()
+	defer lt.mtx.Unlock()
+
+	if 0 < lt.limit.Rate {
+		lt.refill(time.Now())
+	}
+	return LimiterStats{
+		InFlight:    len(lt.slots),
+		MaxInFlight: lt.limit.MaxInFlight,
+		Queued:      lt.queued,
+		QueueLength: lt.limit.QueueLength,
+		Tokens:      lt.tokens,
+		Rate:        lt.limit.Rate,
+		Accepted:    lt.accepted,
+		Rejected:    lt.rejected,
+	}
+}
+
+func (lt *Limiter) takeToken(c context.Context, name string, deadline <-chan time.Time) error {
+	if 0 >= lt.limit.Rate {
+		return nil
+	}
+
+	lt.mtx.Lock()
+	lt.refill(time.Now())
+	if 1 <= lt.tokens {
+		lt.tokens--
+		lt.mtx.Unlock()
+		return nil
+	}
+
+	wait := time.Duration((1 - lt.tokens) / lt.limit.Rate * float64(time.Second))
+	if LimitWait != lt.limit.Policy || (0 < lt.limit.Timeout && wait > lt.limit.Timeout) {
+		lt.rejected++
+		lt.mtx.Unlock()
+		return ErrLimitExceeded{name: name, reason: "rate limit exceeded"}
+	}
+	if err := lt.enqueue(name); nil != err {
+		lt.mtx.Unlock()
+		return err
+	}
+	// Reserve the token that will be available after wait.
+	lt.tokens--
+	lt.mtx.Unlock()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case <-timer.C:
+	case <-deadline:
+		err = ErrLimitExceeded{name: name, reason: "timed out waiting for a token"}
+	case <-c.Done():
+		err = ErrLimitExceeded{name: name, reason: "cancelled waiting for a token"}
+	}
+
+	lt.mtx.Lock()
+	lt.queued--
+	if nil != err {
+		lt.refund()
+		lt.rejected++
+	}
+	lt.mtx.Unlock()
+	return err
+}
+
+func (lt *Limiter) takeSlot(c context.Context, name string, deadline <-chan time.Time) error {
+	if nil == lt.slots {
+		return nil
+	}
+
+	select {
+	case lt.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	lt.mtx.Lock()
+	if LimitWait != lt.limit.Policy {
+		lt.rejected++
+		lt.mtx.Unlock()
+		return ErrLimitExceeded{name: name, reason: "too many calls in flight"}
+	}
+	if err := lt.enqueue(name); nil != err {
+		lt.mtx.Unlock()
+		return err
+	}
+	lt.mtx.Unlock()
+
+	var err error
+	select {
+	case lt.slots <- struct{}{}:
+	case <-deadline:
+		err = ErrLimitExceeded{name: name, reason: "timed out waiting for a free slot"}
+	case <-c.Done():
+		err = ErrLimitExceeded{name: name, reason: "cancelled waiting for a free slot"}
+	}
+
+	lt.mtx.Lock()
+	lt.queued--
+	if nil != err {
+		lt.rejected++
+	}
+	lt.mtx.Unlock()
+	return err
+}
+
+// enqueue counts a call which waits, or rejects it if the queue is full.
+// The caller must hold mtx.
+func (lt *Limiter) enqueue(name string) error {
+	if 0 < lt.limit.QueueLength && lt.queued >= lt.limit.QueueLength {
+		lt.rejected++
+		return ErrLimitExceeded{name: name, reason: "queue is full"}
+	}
+	lt.queued++
+	return nil
+}
+
+// refund returns a token which has been taken for a call that is not made.
+// The caller must hold mtx.
+func (lt *Limiter) refund() {
+	lt.tokens = math.Min(float64(lt.limit.Burst), lt.tokens+1)
+}
+
+func (lt *Limiter) refill(now time.Time) {
+	elapsed := now.Sub(lt.last).Seconds()
+	lt.last = now
+	if 0 >= elapsed {
+		return
+	}
+	lt.tokens += elapsed * lt.limit.Rate
+	if burst := float64(lt.limit.Burst); lt.tokens > burst {
+		lt.tokens = burst
+	}
+}
+
+// SetLimit sets the Limit of a service or a method.
+//
+// name is either "Service" or "Service.Method" of a registered service.
+func (r *Registry) SetLimit(name string, l Limit) error {
+	name, err := r.resolveName(name)
+	if nil != err {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if nil == r.limiters {
+		r.limiters = make(map[string]*Limiter)
+	}
+	r.limiters[name] = NewLimiter(l)
+	r.publish()
+	return nil
+}
+
+// RemoveLimit removes the Limit of a service or a method.
+//
+// name is resolved as by SetLimit, an unknown name is an error.
+func (r *Registry) RemoveLimit(name string) error {
+	name, err := r.resolveName(name)
+	if nil != err {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.limiters, name)
+	r.publish()
+	return nil
+}
+
+// Limiter returns the Limiter of a service or a method, or nil if it is not
+// limited or unknown. name is resolved as by SetLimit.
+func (r *Registry) Limiter(name string) *Limiter {
+	name, err := r.resolveName(name)
+	if nil != err {
+		return nil
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.limiters[name]
+}
+
+// LimiterStats returns the utilization of all limiters by their names.
+func (r *Registry) LimiterStats() map[string]LimiterStats {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	stats := make(map[string]LimiterStats, len(r.limiters))
+	for name, lt := range r.limiters {
+		stats[name] = lt.Stats()
+	}
+	return stats
+}
+
+// resolveName returns the registered name of "Service" or "Service.Method",
+// where Method may also be an alias.
+func (r *Registry) resolveName(name string) (string, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	sName, mName := name, ""
+	if parts := strings.SplitN(name, ".", 2); 2 == len(parts) {
+		sName, mName = parts[0], parts[1]
+	}
+	s := r.services[sName]
+	if nil == s {
+		return "", fmt.Errorf("Registry: can't find service %q", name)
+	}
+	return s.resolveName(mName)
+}
+
+// resolveName returns the registered name of the service if mName is "",
+// else of its method mName, which may also be an alias.
+func (s *ServiceMeta) resolveName(mName string) (string, error) {
+	if "" == mName {
+		return s.name, nil
+	}
+	mm := s.methods[mName]
+	if nil == mm {
+		return "", fmt.Errorf("Registry: can't find method %q", s.name+"."+mName)
+	}
+	return mm.fullName, nil
+}
+
+// acquireLimits takes the limits of the service and then of the method.
+// Waits end when the context.Context of the call is done.
+// release is nil if neither is limited.
+func (r *Registry) acquireLimits(c ctx.Ctx, service string, method string) (release func(), err error) {
+	limiters := r.load().limiters
+	if 0 == len(limiters) {
+		return nil, nil
+	}
+	sl := limiters[service]
+	ml := limiters[method]
+	if nil == sl && nil == ml {
+		return nil, nil
+	}
+
+	sRelease := func() {}
+	if nil != sl {
+		if sRelease, err = sl.AcquireContext(ContextOf(c), service); nil != err {
+			return nil, err
+		}
+	}
+	if nil != ml {
+		mRelease, err := ml.AcquireContext(ContextOf(c), method)
+		if nil != err {
+			sRelease()
+			return nil, err
+		}
+		return func() {
+			mRelease()
+			sRelease()
+		}, nil
+	}
+	return sRelease, nil
+}
+
+// limitersOf returns the limiters of the limits declared by a Limited
+// receiver by their registered names.
+func limitersOf(s *ServiceMeta) (map[string]*Limiter, error) {
+	limited, ok := s.rcvrV.Interface().(Limited)
+	if !ok {
+		return nil, nil
+	}
+	limiters := make(map[string]*Limiter)
+	for mName, l := range limited.Limits() {
+		if "" != mName {
+			mName = s.methodName(mName)
+		}
+		name, err := s.resolveName(mName)
+		if nil != err {
+			return nil, err
+		}
+		limiters[name] = NewLimiter(l)
+	}
+	return limiters, nil
+}
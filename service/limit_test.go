@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLimiterMaxInFlight(t *testing.T) {
+	lt := NewLimiter(Limit{MaxInFlight: 2})
+
+	r1, err := lt.Acquire("test")
+	if nil != err {
+		t.Fatal(err)
+	}
+	r2, err := lt.Acquire("test")
+	if nil != err {
+		t.Fatal(err)
+	}
+	if _, err := lt.Acquire("test"); nil == err {
+		t.Errorf("Acquire() over MaxInFlight succeeded")
+	} else if _, ok := err.(ErrLimitExceeded); !ok {
+		t.Errorf("Acquire() error = %T, want ErrLimitExceeded", err)
+	}
+
+	stats := lt.Stats()
+	if 2 != stats.InFlight || 1 != stats.Utilization() || 1 != stats.Rejected {
+		t.Errorf("Stats() = %+v", stats)
+	}
+
+	r1()
+	r1()
+	if 1 != lt.Stats().InFlight {
+		t.Errorf("release is not idempotent")
+	}
+	r2()
+}
+
+func TestLimiterWait(t *testing.T) {
+	lt := NewLimiter(Limit{MaxInFlight: 1, QueueLength: 1, Policy: LimitWait, Timeout: 20 * time.Millisecond})
+
+	release, err := lt.Acquire("test")
+	if nil != err {
+		t.Fatal(err)
+	}
+	if _, err := lt.Acquire("test"); nil == err {
+		t.Errorf("Acquire() did not time out")
+	}
+
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		release()
+	}()
+	r, err := lt.Acquire("test")
+	if nil != err {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	r()
+}
+
+func TestLimiterRate(t *testing.T) {
+	lt := NewLimiter(Limit{Rate: 1, Burst: 2})
+
+	for indexI := 0; indexI < 2; indexI++ {
+		if _, err := lt.Acquire("test"); nil != err {
+			t.Fatalf("Acquire() in burst error = %v", err)
+		}
+	}
+	if _, err := lt.Acquire("test"); nil == err {
+		t.Errorf("Acquire() over rate succeeded")
+	}
+}
+
+func TestLimiterRefund(t *testing.T) {
+	lt := NewLimiter(Limit{MaxInFlight: 1, Rate: 1000, Burst: 2})
+	release, err := lt.Acquire("test")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer release()
+	time.Sleep(5 * time.Millisecond)
+	// The token of a call rejected for its slot is refunded up to Burst.
+	if _, err := lt.Acquire("test"); nil == err {
+		t.Fatal("Acquire() over MaxInFlight succeeded")
+	}
+	if tokens := lt.Stats().Tokens; 2 < tokens {
+		t.Errorf("Stats().Tokens = %v, want at most Burst", tokens)
+	}
+}
+
+func TestLimiterRateWait(t *testing.T) {
+	lt := NewLimiter(Limit{Rate: 10, QueueLength: 1, Policy: LimitWait})
+	if _, err := lt.Acquire("test"); nil != err {
+		t.Fatal(err)
+	}
+
+	c, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := lt.AcquireContext(c, "test")
+		done <- err
+	}()
+	for 0 == lt.Stats().Queued {
+		time.Sleep(time.Millisecond)
+	}
+	// The queue bounds waits for tokens too.
+	if _, err := lt.Acquire("test"); nil == err {
+		t.Errorf("Acquire() with a full queue succeeded")
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if _, ok := err.(ErrLimitExceeded); !ok {
+			t.Errorf("AcquireContext() error = %v, want ErrLimitExceeded", err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("AcquireContext() did not return when cancelled")
+	}
+	if stats := lt.Stats(); 0 != stats.Queued || 1 < stats.Tokens {
+		t.Errorf("Stats() = %+v", stats)
+	}
+}
+
+type LimitedArith struct {
+	Arith
+}
+
+func (a *LimitedArith) Limits() map[string]Limit {
+	return map[string]Limit{
+		"Add": Limit{Rate: 1},
+	}
+}
+
+func TestRegistryLimits(t *testing.T) {
+	r := &Registry{}
+	if err := r.Register(&LimitedArith{}, ""); nil != err {
+		t.Fatal(err)
+	}
+	if nil == r.Limiter("LimitedArith.Add") {
+		t.Fatalf("Limits() of the receiver are not registered")
+	}
+	if err := r.SetLimit("LimitedArith.Mul", Limit{}); nil == err {
+		t.Errorf("SetLimit() of unknown method succeeded")
+	}
+
+	decode := func(instances []interface{}) error {
+		*(instances[0].(*int)) = 1
+		*(instances[1].(*int)) = 2
+		return nil
+	}
+	if _, err := r.Invoke(nil, "LimitedArith.Add", decode); nil != err {
+		t.Fatal(err)
+	}
+	if _, err := r.Invoke(nil, "LimitedArith.Add", decode); nil == err {
+		t.Errorf("Invoke() over rate succeeded")
+	}
+	if _, err := r.Invoke(nil, "LimitedArith.Div", decode); nil != err {
+		t.Errorf("Invoke() of unlimited method error = %v", err)
+	}
+}
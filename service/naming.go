@@ -0,0 +1,127 @@
+package service
+
+import (
+	"fmt"
+	"unicode"
+)
+
+// NamingStrategy returns the name under which a service or a method is
+// registered given its Go identifier.
+type NamingStrategy func(name string) string
+
+// GoNaming registers services and methods under their Go identifiers.
+func GoNaming(name string) string {
+	return name
+}
+
+// LowerCamelCaseNaming registers services and methods in lowerCamelCase,
+// e.g. "GetHost" as "getHost" and "HTTPServer" as "httpServer".
+func LowerCamelCaseNaming(name string) string {
+	runes := []rune(name)
+	for indexI := 0; indexI < len(runes); indexI++ {
+		if !unicode.IsUpper(runes[indexI]) {
+			break
+		}
+		// Keep the last upper case letter of an acronym which starts a word.
+		if 0 < indexI && indexI+1 < len(runes) && unicode.IsLower(runes[indexI+1]) {
+			break
+		}
+		runes[indexI] = unicode.ToLower(runes[indexI])
+	}
+	return string(runes)
+}
+
+// Aliased is implemented by receivers that register methods under
+// additional names. The keys are Go method names.
+type Aliased interface {
+	Aliases() map[string][]string
+}
+
+// Excluder is implemented by receivers that have exported methods which
+// must not be registered. The names are Go method names.
+type Excluder interface {
+	ExcludedMethods() []string
+}
+
+// SetNaming sets the strategies applied to the names of services and
+// methods registered afterwards. A service registered with an explicit name
+// keeps it. nil means GoNaming.
+func (r *Registry) SetNaming(service NamingStrategy, method NamingStrategy) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.serviceNaming = service
+	r.methodNaming = method
+}
+
+func (r *Registry) namings() (service NamingStrategy, method NamingStrategy) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	service, method = r.serviceNaming, r.methodNaming
+	if nil == service {
+		service = GoNaming
+	}
+	if nil == method {
+		method = GoNaming
+	}
+	return
+}
+
+// excludedMethods returns the Go names of the methods rcvr excludes.
+func excludedMethods(rcvr interface{}) map[string]bool {
+	names := make(map[string]bool)
+	if excluder, ok := rcvr.(Excluder); ok {
+		for _, name := range excluder.ExcludedMethods() {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// addMethod adds mm under the name given by naming for the Go method name.
+func (s *ServiceMeta) addMethod(goName string, naming NamingStrategy, mm *MethodMeta) error {
+	name := naming(goName)
+	if "" == name {
+		return fmt.Errorf("Registry: no method name for %q of %q", goName, s.name)
+	}
+	if _, ok := s.methods[name]; ok {
+		return fmt.Errorf("Registry: method already defined: %q of %q", name, s.name)
+	}
+	mm.name = name
+	mm.fullName = s.name + "." + name
+	s.methods[name] = mm
+	s.names[goName] = name
+	return nil
+}
+
+// addAliases adds the aliases declared by an Aliased receiver.
+func (s *ServiceMeta) addAliases(rcvr interface{}) error {
+	aliased, ok := rcvr.(Aliased)
+	if !ok {
+		return nil
+	}
+	for goName, aliases := range aliased.Aliases() {
+		name, ok := s.names[goName]
+		if !ok {
+			return fmt.Errorf("Registry: alias of unknown method %q of %q", goName, s.name)
+		}
+		for _, alias := range aliases {
+			if "" == alias {
+				return fmt.Errorf("Registry: empty alias of %q of %q", goName, s.name)
+			}
+			if _, ok := s.methods[alias]; ok {
+				return fmt.Errorf("Registry: alias already defined: %q of %q", alias, s.name)
+			}
+			s.methods[alias] = s.methods[name]
+		}
+	}
+	return nil
+}
+
+// methodName returns the registered name of a method given by its Go name
+// or by its registered name.
+func (s *ServiceMeta) methodName(name string) string {
+	if registered, ok := s.names[name]; ok {
+		return registered
+	}
+	return name
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"testing"
+)
+
+func TestLowerCamelCaseNaming(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Get", "get"},
+		{"GetHost", "getHost"},
+		{"HTTPServer", "httpServer"},
+		{"ID", "id"},
+		{"get", "get"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := LowerCamelCaseNaming(tt.name); got != tt.want {
+			t.Errorf("LowerCamelCaseNaming(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+type Legacy struct{}
+
+func (l *Legacy) GetHost() (string, error) { return "host", nil }
+func (l *Legacy) Internal() error          { return nil }
+
+func (l *Legacy) Aliases() map[string][]string {
+	return map[string][]string{
+		"GetHost": []string{"fetchHost", "GetHostV1"},
+	}
+}
+
+func (l *Legacy) ExcludedMethods() []string {
+	return []string{"Internal"}
+}
+
+func (l *Legacy) Limits() map[string]Limit {
+	return map[string]Limit{
+		"GetHost": Limit{Rate: 1},
+	}
+}
+
+func TestRegistryNaming(t *testing.T) {
+	r := &Registry{}
+	r.SetNaming(LowerCamelCaseNaming, LowerCamelCaseNaming)
+	if err := r.Register(&Legacy{}, ""); nil != err {
+		t.Fatal(err)
+	}
+
+	for _, method := range []string{"legacy.getHost", "legacy.fetchHost", "legacy.GetHostV1"} {
+		if _, mm, err := r.Get(method); nil != err {
+			t.Errorf("Get(%s) error = %v", method, err)
+		} else if "legacy.getHost" != mm.fullName {
+			t.Errorf("Get(%s) = %s", method, mm.fullName)
+		}
+	}
+	for _, method := range []string{"Legacy.GetHost", "legacy.GetHost", "legacy.internal", "legacy.Internal"} {
+		if _, _, err := r.Get(method); nil == err {
+			t.Errorf("Get(%s) succeeded", method)
+		}
+	}
+
+	if nil == r.Limiter("legacy.getHost") {
+		t.Fatalf("limit of the Go method name is not registered")
+	}
+	if _, err := r.Invoke(nil, "legacy.fetchHost", nil); nil != err {
+		t.Fatal(err)
+	}
+	if _, err := r.Invoke(nil, "legacy.getHost", nil); nil == err {
+		t.Errorf("alias does not share the limit of the method")
+	}
+
+	if err := r.SetLimit("legacy.fetchHost", Limit{Rate: 2}); nil != err {
+		t.Fatal(err)
+	}
+	if lt := r.Limiter("legacy.fetchHost"); nil == lt || lt != r.Limiter("legacy.getHost") {
+		t.Errorf("Limiter() of the alias = %v", lt)
+	}
+	if err := r.RemoveLimit("legacy.fetchHost"); nil != err {
+		t.Fatal(err)
+	}
+	if nil != r.Limiter("legacy.getHost") {
+		t.Errorf("RemoveLimit() of the alias kept the limit")
+	}
+	if err := r.RemoveLimit("legacy.unknown"); nil == err {
+		t.Errorf("RemoveLimit() of unknown method error = nil")
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"reflect"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 	"unicode"
 	"unicode/utf8"
 )
@@ -22,7 +24,11 @@ type ServiceMeta struct {
 	name    string                 // name of service
 	rcvrV   reflect.Value          // receiver of methods for the service
 	rcvrT   reflect.Type           // type of the receiver
-	methods map[string]*MethodMeta // registered methods
+	methods map[string]*MethodMeta // registered methods, also by aliases
+	names   map[string]string      // registered method names by Go names
+
+	authorizer Authorizer  // receiver as Authorizer, or nil
+	injections []injection // fields filled with other services
 }
 
 func (r *ServiceMeta) ReceiverType() reflect.Type {
@@ -34,9 +40,16 @@ func (r *ServiceMeta) ReceiverValue() reflect.Value {
 }
 
 type MethodMeta struct {
+	name       string         // registered name of the method
+	fullName   string         // registered name as in "Service.Method"
 	method     reflect.Method // receiver method
 	paramTypes []reflect.Type // type of the request argument
 	returnType reflect.Type   // type of the response argument
+
+	params      []paramMeta  // cached allocators of the arguments
+	decodeCount int          // number of arguments which are not ctx.Ctx
+	hasCtx      bool         // true if an argument is ctx.Ctx
+	invoker     typedInvoker // pre-bound invoker, nil for the reflect path
 }
 
 func (mm *MethodMeta) Call(in []reflect.Value) []reflect.Value {
@@ -52,41 +65,18 @@ func (mm *MethodMeta) ParamValues() (values []reflect.Value, instances []interfa
 		return nil, nil
 	}
 
-	pCount := len(mm.paramTypes)
+	pCount := len(mm.params)
 	values = make([]reflect.Value, pCount)
 	instances = make([]interface{}, pCount)
 
 	for indexI := 0; indexI < pCount; indexI++ {
-		values[indexI] = getValue(mm.paramTypes[indexI])
-		instances[indexI] = values[indexI].Interface()
+		instances[indexI] = mm.params[indexI].alloc()
+		values[indexI] = reflect.ValueOf(instances[indexI])
 	}
 
 	return
 }
 
-func getValue(t reflect.Type) reflect.Value {
-	rt := t
-	if rt.Kind() == reflect.Ptr {
-		rt = rt.Elem()
-	}
-
-	// rv := reflect.New(rt)
-	// if rt.Kind() != reflect.Struct {
-	// 	rv = reflect.Indirect(rv)
-	// }
-
-	// var rv reflect.Value
-
-	// 	switch rt.Kind() {
-	// 	case reflect.Slice:
-	// 		rv = reflect.New(reflect.SliceOf(rt.Elem()))
-	// 	default:
-	// 		rv = reflect.New(rt)
-	// 	}
-
-	return reflect.New(rt)
-}
-
 // ----------------------------------------------------------------------------
 // Registry
 // ----------------------------------------------------------------------------
@@ -95,6 +85,24 @@ func getValue(t reflect.Type) reflect.Value {
 type Registry struct {
 	mutex    sync.RWMutex
 	services map[string]*ServiceMeta
+	limiters map[string]*Limiter
+	roles    map[string][]string
+	// serviceNaming and methodNaming name services and methods on Register.
+	serviceNaming NamingStrategy
+	methodNaming  NamingStrategy
+	// interceptors are called around every Invoke, the first one outermost.
+	interceptors []Interceptor
+	// exporters receive the spans of all calls.
+	exporters []SpanExporter
+	// snapshot is a copy of services and limiters which is replaced on every
+	// change, so that lookups on the call path do not take the mutex.
+	snapshot atomic.Value
+
+	lifecycleMutex sync.Mutex
+	started        []*ServiceMeta
+	// stopTimeout bounds each Stop when StartAll fails, 0 means
+	// DefaultStopTimeout.
+	stopTimeout time.Duration
 }
 
 func (r *Registry) GetService(name string) interface{} {
@@ -114,21 +122,28 @@ func (r *Registry) Register(rcvr interface{}, name string) error {
 		rcvrV:   reflect.ValueOf(rcvr),
 		rcvrT:   reflect.TypeOf(rcvr),
 		methods: make(map[string]*MethodMeta),
+		names:   make(map[string]string),
 	}
+	s.authorizer, _ = rcvr.(Authorizer)
+	serviceNaming, methodNaming := r.namings()
 	if name == "" {
 		s.name = reflect.Indirect(s.rcvrV).Type().Name()
 		if !isExported(s.name) {
 			return fmt.Errorf("Registry: type %q is not exported", s.name)
 		}
+		s.name = serviceNaming(s.name)
 	}
 	if s.name == "" {
 		return fmt.Errorf("Registry: no service name for type %q",
 			s.rcvrT.String())
 	}
 
-	var err error
+	excluded := excludedMethods(rcvr)
+	var bound map[string]interface{}
+	if binder, ok := rcvr.(Binder); ok {
+		bound = binder.BoundMethods()
+	}
 	// Setup methods.
-Loop:
 	for i := 0; i < s.rcvrT.NumMethod(); i++ {
 		m := s.rcvrT.Method(i)
 		mt := m.Type
@@ -136,56 +151,99 @@ Loop:
 		if m.PkgPath != "" {
 			continue
 		}
+		// Lifecycle and excluded methods are not callable.
+		if excluded[m.Name] {
+			continue
+		}
 
-		var paramTypes []reflect.Type
-		var returnType reflect.Type
-
-		pCount := mt.NumIn() - 1
+		paramTypes, returnType, ok, err := signatureOf(mt, 1)
+		if nil != err {
+			return err
+		}
+		if !ok {
+			continue
+		}
 
-		if 0 < pCount {
-			paramTypes = make([]reflect.Type, pCount)
+		fn := bound[m.Name]
+		if nil != fn && reflect.TypeOf(fn) != s.rcvrV.Method(i).Type() {
+			return fmt.Errorf("Registry: bound method %q of %q is %s but must be %s",
+				m.Name, s.name, reflect.TypeOf(fn), s.rcvrV.Method(i).Type())
+		}
 
-			for indexI := 0; indexI < pCount; indexI++ {
-				pt := mt.In(indexI + 1)
-				if err = validateType(mt.In(indexI + 1)); nil != err {
-					return err
-				}
-				paramTypes[indexI] = pt
-			}
+		mm := newMethodMeta(m, paramTypes, returnType, fn, s.rcvrV.Method(i).Interface())
+		if err = s.addMethod(m.Name, methodNaming, mm); nil != err {
+			return err
 		}
+	}
+	if len(s.methods) == 0 {
+		return fmt.Errorf("Registry: %q has no exported methods of suitable type", s.name)
+	}
+	if err := s.addAliases(rcvr); nil != err {
+		return err
+	}
+	var err error
+	if s.injections, err = injectionsOf(s); nil != err {
+		return err
+	}
+	return r.add(s)
+}
 
-		switch mt.NumOut() {
-		case 1:
-			if t := mt.Out(0); t != typeOfError {
-				continue Loop
-			}
-		case 2:
-			if t := mt.Out(0); !isExportedOrBuiltin(t) {
-				continue Loop
-			}
+// signatureOf returns the parameter and return types of a method of type mt
+// whose parameters start at first. ok is false if the method can not be
+// registered because of its return types.
+func signatureOf(mt reflect.Type, first int) (paramTypes []reflect.Type, returnType reflect.Type, ok bool, err error) {
+	pCount := mt.NumIn() - first
 
-			if t := mt.Out(1); t != typeOfError {
-				continue Loop
-			}
-			rt := mt.Out(0)
-			if err = validateType(rt); nil != err {
-				return err
+	if 0 < pCount {
+		paramTypes = make([]reflect.Type, pCount)
+
+		for indexI := 0; indexI < pCount; indexI++ {
+			pt := mt.In(indexI + first)
+			if err = validateType(pt); nil != err {
+				return nil, nil, false, err
 			}
-			returnType = rt
-		default:
-			continue
+			paramTypes[indexI] = pt
+		}
+	}
+
+	switch mt.NumOut() {
+	case 1:
+		if t := mt.Out(0); t != typeOfError {
+			return nil, nil, false, nil
+		}
+	case 2:
+		if t := mt.Out(0); !isExportedOrBuiltin(t) {
+			return nil, nil, false, nil
 		}
 
-		s.methods[m.Name] = &MethodMeta{
-			method:     m,
-			paramTypes: paramTypes,
-			returnType: returnType,
+		if t := mt.Out(1); t != typeOfError {
+			return nil, nil, false, nil
 		}
+		rt := mt.Out(0)
+		if err = validateType(rt); nil != err {
+			return nil, nil, false, err
+		}
+		returnType = rt
+	default:
+		return nil, nil, false, nil
 	}
-	if len(s.methods) == 0 {
-		return fmt.Errorf("Registry: %q has no exported methods of suitable type", s.name)
+	return paramTypes, returnType, true, nil
+}
+
+// add adds a service to the map, wires it with the registered services and
+// sets the limits and roles it declares.
+// The limits and roles are built first, so that the service is published
+// together with them and is never callable without them.
+func (r *Registry) add(s *ServiceMeta) error {
+	limiters, err := limitersOf(s)
+	if nil != err {
+		return err
+	}
+	roles, err := rolesOf(s)
+	if nil != err {
+		return err
 	}
-	// Add to the map.
+
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 	if r.services == nil {
@@ -193,10 +251,68 @@ Loop:
 	} else if _, ok := r.services[s.name]; ok {
 		return fmt.Errorf("Registry: service already defined: %q", s.name)
 	}
+	if err = r.wire(s); nil != err {
+		return err
+	}
 	r.services[s.name] = s
+	if 0 < len(limiters) && nil == r.limiters {
+		r.limiters = make(map[string]*Limiter)
+	}
+	for name, lt := range limiters {
+		r.limiters[name] = lt
+	}
+	if 0 < len(roles) && nil == r.roles {
+		r.roles = make(map[string][]string)
+	}
+	for name, rs := range roles {
+		r.roles[name] = rs
+	}
+	r.publish()
 	return nil
 }
 
+type registrySnapshot struct {
+	services     map[string]*ServiceMeta
+	limiters     map[string]*Limiter
+	roles        map[string][]string
+	interceptors []Interceptor
+	exporters    []SpanExporter
+}
+
+// publish replaces the snapshot with a copy of services, limiters, roles,
+// interceptors and exporters.
+// The caller must hold the write lock of mutex.
+func (r *Registry) publish() {
+	snapshot := &registrySnapshot{
+		services: make(map[string]*ServiceMeta, len(r.services)),
+		limiters: make(map[string]*Limiter, len(r.limiters)),
+		roles:    make(map[string][]string, len(r.roles)),
+		// Intercept and Trace never modify the backing arrays, so they can
+		// be shared.
+		interceptors: r.interceptors,
+		exporters:    r.exporters,
+	}
+	for name, s := range r.services {
+		snapshot.services[name] = s
+	}
+	for name, lt := range r.limiters {
+		snapshot.limiters[name] = lt
+	}
+	for name, roles := range r.roles {
+		snapshot.roles[name] = roles
+	}
+	r.snapshot.Store(snapshot)
+}
+
+// load returns the current snapshot, which must not be modified.
+func (r *Registry) load() *registrySnapshot {
+	snapshot, _ := r.snapshot.Load().(*registrySnapshot)
+	if nil == snapshot {
+		return &registrySnapshot{}
+	}
+	return snapshot
+}
+
 func validateType(t reflect.Type) error {
 	if t.Kind() == reflect.Struct {
 		return fmt.Errorf("Type is Struct. Pass by reference, i.e. *%s", t)
@@ -208,21 +324,19 @@ func validateType(t reflect.Type) error {
 //
 // The method name uses a dotted notation as in "Service.Method".
 func (r *Registry) Get(method string) (*ServiceMeta, *MethodMeta, error) {
-	parts := strings.Split(method, ".")
-	if len(parts) != 2 {
-		err := fmt.Errorf("Registry: service/method request ill-formed: %q", method)
+	dot := strings.IndexByte(method, '.')
+	if dot < 0 || strings.IndexByte(method[dot+1:], '.') >= 0 {
+		err := ErrMethodNotFound{method: method, reason: "service/method request ill-formed:"}
 		return nil, nil, err
 	}
-	r.mutex.Lock()
-	service := r.services[parts[0]]
-	r.mutex.Unlock()
+	service := r.load().services[method[:dot]]
 	if service == nil {
-		err := fmt.Errorf("Registry: can't find service %q", method)
+		err := ErrMethodNotFound{method: method, reason: "can't find service"}
 		return nil, nil, err
 	}
-	MethodMeta := service.methods[parts[1]]
+	MethodMeta := service.methods[method[dot+1:]]
 	if MethodMeta == nil {
-		err := fmt.Errorf("Registry: can't find method %q", method)
+		err := ErrMethodNotFound{method: method, reason: "can't find method"}
 		return nil, nil, err
 	}
 	return service, MethodMeta, nil
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"testing"
+)
+
+type Echo struct{}
+
+func (e *Echo) Ping() error {
+	return nil
+}
+
+func (e *Echo) Echo(s string) (string, error) {
+	return s, nil
+}
+
+func (e *Echo) Concat(a string, b string, n int) (string, error) {
+	return a + b, nil
+}
+
+type BoundEcho struct{}
+
+func (e *BoundEcho) Ping() error {
+	return nil
+}
+
+func (e *BoundEcho) Echo(s string) (string, error) {
+	return s, nil
+}
+
+func (e *BoundEcho) BoundMethods() map[string]interface{} {
+	return map[string]interface{}{
+		"Ping": e.Ping,
+		"Echo": e.Echo,
+	}
+}
+
+func newBenchmarkRegistry(b *testing.B) *Registry {
+	r := &Registry{}
+	if err := r.Register(&Echo{}, ""); nil != err {
+		b.Fatal(err)
+	}
+	if err := r.Register(&BoundEcho{}, ""); nil != err {
+		b.Fatal(err)
+	}
+	return r
+}
+
+func BenchmarkRegistryGet(b *testing.B) {
+	r := newBenchmarkRegistry(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for indexI := 0; indexI < b.N; indexI++ {
+		if _, _, err := r.Get("Echo.Echo"); nil != err {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRegistryGetParallel(b *testing.B) {
+	r := newBenchmarkRegistry(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, _, err := r.Get("Echo.Echo"); nil != err {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkMethodMetaParamValues(b *testing.B) {
+	r := newBenchmarkRegistry(b)
+	_, mm, _ := r.Get("Echo.Concat")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for indexI := 0; indexI < b.N; indexI++ {
+		mm.ParamValues()
+	}
+}
+
+func benchmarkInvoke(b *testing.B, method string, decode ParamDecoder) {
+	r := newBenchmarkRegistry(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for indexI := 0; indexI < b.N; indexI++ {
+		if _, err := r.Invoke(nil, method, decode); nil != err {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRegistryInvokeNoParams(b *testing.B) {
+	benchmarkInvoke(b, "Echo.Ping", nil)
+}
+
+func BenchmarkRegistryInvokeEcho(b *testing.B) {
+	benchmarkInvoke(b, "Echo.Echo", func(instances []interface{}) error {
+		*(instances[0].(*string)) = "echo"
+		return nil
+	})
+}
+
+func BenchmarkRegistryInvokeBoundNoParams(b *testing.B) {
+	benchmarkInvoke(b, "BoundEcho.Ping", nil)
+}
+
+func BenchmarkRegistryInvokeBoundEcho(b *testing.B) {
+	benchmarkInvoke(b, "BoundEcho.Echo", func(instances []interface{}) error {
+		*(instances[0].(*string)) = "echo"
+		return nil
+	})
+}
+
+func BenchmarkRegistryInvokeConcat(b *testing.B) {
+	benchmarkInvoke(b, "Echo.Concat", func(instances []interface{}) error {
+		*(instances[0].(*string)) = "a"
+		*(instances[1].(*string)) = "b"
+		*(instances[2].(*int)) = 1
+		return nil
+	})
+}
+
+func BenchmarkRegistryInvokeEchoReflect(b *testing.B) {
+	r := newBenchmarkRegistry(b)
+	_, mm, _ := r.Get("Echo.Echo")
+	mm.invoker = nil
+	b.ReportAllocs()
+	b.ResetTimer()
+	for indexI := 0; indexI < b.N; indexI++ {
+		if _, err := r.Invoke(nil, "Echo.Echo", func(instances []interface{}) error {
+			*(instances[0].(*string)) = "echo"
+			return nil
+		}); nil != err {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestTypedInvoker(t *testing.T) {
+	r := &Registry{}
+	if err := r.Register(&Echo{}, ""); nil != err {
+		t.Fatal(err)
+	}
+	_, mm, _ := r.Get("Echo.Echo")
+	if nil == mm.invoker {
+		t.Fatalf("Echo.Echo has no typed invoker")
+	}
+	_, mm, _ = r.Get("Echo.Concat")
+	if nil != mm.invoker {
+		t.Fatalf("Echo.Concat has a typed invoker")
+	}
+
+	decode := func(instances []interface{}) error {
+		for indexI, instance := range instances {
+			switch v := instance.(type) {
+			case *string:
+				*v = string('a' + rune(indexI))
+			case *int:
+				*v = indexI
+			}
+		}
+		return nil
+	}
+	tests := []struct {
+		method string
+		want   interface{}
+	}{
+		{"Echo.Ping", nil},
+		{"Echo.Echo", "a"},
+		{"Echo.Concat", "ab"},
+	}
+	for _, tt := range tests {
+		got, err := r.Invoke(nil, tt.method, decode)
+		if nil != err {
+			t.Errorf("Invoke(%s) error = %v", tt.method, err)
+		}
+		if got != tt.want {
+			t.Errorf("Invoke(%s) = %v, want %v", tt.method, got, tt.want)
+		}
+	}
+}
+
+type BadBoundEcho struct {
+	BoundEcho
+}
+
+func (e *BadBoundEcho) BoundMethods() map[string]interface{} {
+	return map[string]interface{}{
+		"Echo": e.Ping,
+	}
+}
+
+func TestRegistryBinder(t *testing.T) {
+	r := &Registry{}
+	if err := r.Register(&BadBoundEcho{}, ""); nil == err {
+		t.Errorf("Register() accepted a bound method of another type")
+	}
+	if err := r.Register(&BoundEcho{}, ""); nil != err {
+		t.Fatal(err)
+	}
+	got, err := r.Invoke(nil, "BoundEcho.Echo", func(instances []interface{}) error {
+		*(instances[0].(*string)) = "echo"
+		return nil
+	})
+	if nil != err || "echo" != got {
+		t.Errorf("Invoke() = %v, %v", got, err)
+	}
+}
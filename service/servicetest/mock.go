@@ -0,0 +1,138 @@
+// Package servicetest provides test doubles for service.Registry.
+package servicetest
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/LOAFLE/util-go/ctx"
+	"github.com/LOAFLE/util-go/service"
+)
+
+var (
+	typeOfError = reflect.TypeOf((*error)(nil)).Elem()
+	typeOfCtx   = reflect.TypeOf((*ctx.Ctx)(nil)).Elem()
+)
+
+// Call is an invocation recorded by a Mock.
+type Call struct {
+	// Method is the name of the method without the service.
+	Method string
+	// Params are the decoded parameters, parameters of type ctx.Ctx excluded.
+	Params []interface{}
+	Result interface{}
+	Err    error
+}
+
+// Mock is a fake service whose methods are given by a map.
+type Mock struct {
+	name  string
+	funcs map[string]interface{}
+
+	mtx   sync.Mutex
+	calls []Call
+}
+
+// NewMock returns a Mock of the service name.
+//
+// methods maps method names to the behaviour of the method. A function is
+// called with the decoded parameters, an error is returned by a method
+// without parameters and any other value is the canned result of a method
+// without parameters.
+func NewMock(name string, methods map[string]interface{}) *Mock {
+	m := &Mock{
+		name:  name,
+		funcs: make(map[string]interface{}, len(methods)),
+	}
+	for mName, behaviour := range methods {
+		m.funcs[mName] = m.record(mName, funcOf(behaviour))
+	}
+	return m
+}
+
+// Name returns the name of the service.
+func (m *Mock) Name() string {
+	return m.name
+}
+
+// Register adds the Mock to r.
+func (m *Mock) Register(r *service.Registry) error {
+	return r.RegisterFuncs(m.name, m.funcs)
+}
+
+// Calls returns all recorded calls in order.
+func (m *Mock) Calls() []Call {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return append([]Call(nil), m.calls...)
+}
+
+// CallsOf returns the recorded calls of a method in order.
+func (m *Mock) CallsOf(method string) []Call {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	calls := make([]Call, 0)
+	for _, call := range m.calls {
+		if method == call.Method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Reset forgets the recorded calls.
+func (m *Mock) Reset() {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.calls = nil
+}
+
+// funcOf returns behaviour as a function.
+func funcOf(behaviour interface{}) interface{} {
+	if nil != behaviour && reflect.Func == reflect.TypeOf(behaviour).Kind() {
+		return behaviour
+	}
+	if err, ok := behaviour.(error); ok {
+		return func() error {
+			return err
+		}
+	}
+	return func() (interface{}, error) {
+		return behaviour, nil
+	}
+}
+
+// record wraps fn so that its calls are recorded.
+func (m *Mock) record(method string, fn interface{}) interface{} {
+	fv := reflect.ValueOf(fn)
+	ft := fv.Type()
+	if 0 == ft.NumOut() || typeOfError != ft.Out(ft.NumOut()-1) {
+		panic(fmt.Sprintf("servicetest: %q of %q must return an error", method, m.name))
+	}
+
+	return reflect.MakeFunc(ft, func(in []reflect.Value) []reflect.Value {
+		call := Call{
+			Method: method,
+			Params: make([]interface{}, 0, len(in)),
+		}
+		for indexI, v := range in {
+			if typeOfCtx != ft.In(indexI) {
+				call.Params = append(call.Params, v.Interface())
+			}
+		}
+
+		out := fv.Call(in)
+		if 2 == len(out) {
+			call.Result = out[0].Interface()
+		}
+		if errV := out[len(out)-1]; !errV.IsNil() {
+			call.Err = errV.Interface().(error)
+		}
+
+		m.mtx.Lock()
+		m.calls = append(m.calls, call)
+		m.mtx.Unlock()
+		return out
+	}).Interface()
+}
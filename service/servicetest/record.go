@@ -0,0 +1,144 @@
+package servicetest
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/LOAFLE/util-go/service"
+)
+
+// Record is an invocation captured by a Recorder.
+type Record struct {
+	// Method is the name of the method as in "Service.Method".
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+	Result json.RawMessage   `json:"result,omitempty"`
+	Error  string            `json:"error,omitempty"`
+}
+
+// Recorder captures the invocations of a service.Registry as JSON lines.
+type Recorder struct {
+	mtx    sync.Mutex
+	w      io.Writer
+	enc    *json.Encoder
+	err    error
+	closed bool
+}
+
+// NewRecorder returns a Recorder writing to w.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{
+		w:   w,
+		enc: json.NewEncoder(w),
+	}
+}
+
+// Close stops the capture and closes the writer if it is an io.Closer.
+func (rec *Recorder) Close() error {
+	rec.mtx.Lock()
+	defer rec.mtx.Unlock()
+	if rec.closed {
+		return nil
+	}
+	rec.closed = true
+	if closer, ok := rec.w.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Record adds the Recorder as an interceptor to r.
+func (rec *Recorder) Record(r *service.Registry) {
+	r.Intercept(rec.intercept)
+}
+
+// Err returns the first error that occurred while writing.
+func (rec *Recorder) Err() error {
+	rec.mtx.Lock()
+	defer rec.mtx.Unlock()
+	return rec.err
+}
+
+func (rec *Recorder) intercept(inv *service.Invocation, next service.InvocationHandler) (interface{}, error) {
+	result, err := next(inv)
+
+	record := &Record{
+		Method: inv.Method,
+		Params: make([]json.RawMessage, 0, len(inv.Params)),
+	}
+	werr := func() error {
+		for _, param := range inv.Params {
+			raw, err := json.Marshal(param)
+			if nil != err {
+				return err
+			}
+			record.Params = append(record.Params, raw)
+		}
+		if nil != result {
+			raw, err := json.Marshal(result)
+			if nil != err {
+				return err
+			}
+			record.Result = raw
+		}
+		return nil
+	}()
+	if nil != err {
+		record.Error = err.Error()
+	}
+
+	rec.mtx.Lock()
+	defer rec.mtx.Unlock()
+	if rec.closed {
+		return result, err
+	}
+	if nil == werr {
+		werr = rec.enc.Encode(record)
+	}
+	if nil == rec.err {
+		rec.err = werr
+	}
+
+	return result, err
+}
+
+// RecordToFile captures the invocations of r to the file at path, which is
+// created or truncated. Close the Recorder to close the file.
+func RecordToFile(r *service.Registry, path string) (*Recorder, error) {
+	f, err := os.Create(path)
+	if nil != err {
+		return nil, err
+	}
+	rec := NewRecorder(f)
+	rec.Record(r)
+	return rec, nil
+}
+
+// ReadRecords reads records written by a Recorder.
+func ReadRecords(rd io.Reader) ([]Record, error) {
+	records := make([]Record, 0)
+	dec := json.NewDecoder(bufio.NewReader(rd))
+	for {
+		var record Record
+		if err := dec.Decode(&record); nil != err {
+			if io.EOF == err {
+				return records, nil
+			}
+			return nil, err
+		}
+		records = append(records, record)
+	}
+}
+
+// LoadRecords reads the records of the file at path.
+func LoadRecords(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if nil != err {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadRecords(f)
+}
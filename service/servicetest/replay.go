@@ -0,0 +1,163 @@
+package servicetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/LOAFLE/util-go/service"
+)
+
+// Replay adds a fake service name to r, which has the methods of proto and
+// answers with the results of the matching records.
+//
+// A call matches a record of its registered method name, as a Recorder
+// writes it, if its parameters encode to the same JSON. Records of the same
+// call are answered in order, the last one repeatedly. Calls without a
+// matching record fail. The calls are answered by an interceptor, which
+// Replay adds to r, so interceptors added later are not called for them.
+func Replay(r *service.Registry, name string, proto interface{}, records []Record) error {
+	rp := &replayer{
+		registry: r,
+		prefix:   name + ".",
+		records:  make(map[string][]Record),
+		next:     make(map[string]int),
+	}
+	for _, record := range records {
+		rp.records[record.Method] = append(rp.records[record.Method], record)
+	}
+
+	funcs := make(map[string]interface{})
+	pt := reflect.TypeOf(proto)
+	for indexI := 0; indexI < pt.NumMethod(); indexI++ {
+		m := pt.Method(indexI)
+		if "" != m.PkgPath {
+			continue
+		}
+		// The method type without the receiver.
+		ft := reflect.ValueOf(proto).Method(indexI).Type()
+		if 0 == ft.NumOut() || 2 < ft.NumOut() || typeOfError != ft.Out(ft.NumOut()-1) || ft.IsVariadic() {
+			continue
+		}
+		funcs[m.Name] = unreachableFunc(name+"."+m.Name, ft)
+	}
+	if 0 == len(funcs) {
+		return fmt.Errorf("servicetest: %T has no methods to replay", proto)
+	}
+
+	if err := r.RegisterFuncs(name, funcs); nil != err {
+		return err
+	}
+	r.Intercept(rp.intercept)
+	return nil
+}
+
+type replayer struct {
+	registry *service.Registry
+	prefix   string
+
+	mtx     sync.Mutex
+	records map[string][]Record
+	next    map[string]int
+}
+
+// unreachableFunc returns a func of type ft, which is only called if the
+// interceptor of the replayer is bypassed.
+func unreachableFunc(method string, ft reflect.Type) interface{} {
+	return reflect.MakeFunc(ft, func(in []reflect.Value) []reflect.Value {
+		out := make([]reflect.Value, ft.NumOut())
+		if 2 == ft.NumOut() {
+			out[0] = reflect.Zero(ft.Out(0))
+		}
+		err := fmt.Errorf("servicetest: %s is not called through the replay interceptor", method)
+		out[len(out)-1] = reflect.ValueOf(&err).Elem()
+		return out
+	}).Interface()
+}
+
+// intercept answers the calls of the replayed service with the records of
+// inv.Method, the registered name of the method.
+func (rp *replayer) intercept(inv *service.Invocation, next service.InvocationHandler) (interface{}, error) {
+	if !strings.HasPrefix(inv.Method, rp.prefix) {
+		return next(inv)
+	}
+	_, mm, err := rp.registry.Get(inv.Method)
+	if nil != err {
+		return nil, err
+	}
+
+	params := make([]json.RawMessage, 0, len(inv.Params))
+	for _, param := range inv.Params {
+		raw, err := json.Marshal(param)
+		if nil != err {
+			return nil, err
+		}
+		params = append(params, raw)
+	}
+
+	record, ok := rp.match(inv.Method, params)
+	if !ok {
+		return nil, fmt.Errorf("servicetest: no recorded call of %q matches the params", inv.Method)
+	}
+
+	var result interface{}
+	if rt := mm.ReturnType(); nil != rt {
+		rv := reflect.New(rt)
+		if 0 < len(record.Result) {
+			if err := json.Unmarshal(record.Result, rv.Interface()); nil != err {
+				return nil, err
+			}
+		}
+		result = rv.Elem().Interface()
+	}
+	if "" != record.Error {
+		return result, errors.New(record.Error)
+	}
+	return result, nil
+}
+
+// match returns the next record of method with params.
+func (rp *replayer) match(method string, params []json.RawMessage) (Record, bool) {
+	key, _ := json.Marshal(params)
+
+	rp.mtx.Lock()
+	defer rp.mtx.Unlock()
+
+	matches := make([]Record, 0)
+	for _, record := range rp.records[method] {
+		if equalJSON(params, record.Params) {
+			matches = append(matches, record)
+		}
+	}
+	if 0 == len(matches) {
+		return Record{}, false
+	}
+
+	callKey := method + string(key)
+	index := rp.next[callKey]
+	if index >= len(matches) {
+		index = len(matches) - 1
+	}
+	rp.next[callKey] = index + 1
+	return matches[index], true
+}
+
+func equalJSON(a []json.RawMessage, b []json.RawMessage) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for indexI := range a {
+		var ca, cb bytes.Buffer
+		if nil != json.Compact(&ca, a[indexI]) || nil != json.Compact(&cb, b[indexI]) {
+			return false
+		}
+		if !bytes.Equal(ca.Bytes(), cb.Bytes()) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,145 @@
+package servicetest
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/LOAFLE/util-go/service"
+)
+
+func TestMock(t *testing.T) {
+	m := NewMock("Hosts", map[string]interface{}{
+		"Count": 3,
+		"Fail":  errors.New("fail"),
+		"Lookup": func(name string) (string, error) {
+			return "10.0.0." + name, nil
+		},
+	})
+	r := &service.Registry{}
+	if err := m.Register(r); nil != err {
+		t.Fatal(err)
+	}
+
+	if got, err := r.Invoke(nil, "Hosts.Count", nil); nil != err || 3 != got {
+		t.Errorf("Count = %v, %v", got, err)
+	}
+	if _, err := r.Invoke(nil, "Hosts.Fail", nil); nil == err || "fail" != err.Error() {
+		t.Errorf("Fail error = %v", err)
+	}
+	got, err := r.Invoke(nil, "Hosts.Lookup", func(instances []interface{}) error {
+		*(instances[0].(*string)) = "1"
+		return nil
+	})
+	if nil != err || "10.0.0.1" != got {
+		t.Errorf("Lookup = %v, %v", got, err)
+	}
+
+	calls := m.CallsOf("Lookup")
+	if 1 != len(calls) || !reflect.DeepEqual(calls[0].Params, []interface{}{"1"}) || "10.0.0.1" != calls[0].Result {
+		t.Errorf("CallsOf() = %+v", calls)
+	}
+	if 3 != len(m.Calls()) {
+		t.Errorf("Calls() = %+v", m.Calls())
+	}
+	m.Reset()
+	if 0 != len(m.Calls()) {
+		t.Errorf("Reset() kept calls")
+	}
+}
+
+type Resolver struct{}
+
+func (r *Resolver) Resolve(name string) (string, error) {
+	if "bad" == name {
+		return "", errors.New("unknown host")
+	}
+	return "10.0.0.1", nil
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	live := &service.Registry{}
+	if err := live.Register(&Resolver{}, ""); nil != err {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	rec := NewRecorder(&buf)
+	rec.Record(live)
+
+	resolve := func(r *service.Registry, name string) (interface{}, error) {
+		return r.Invoke(nil, "Resolver.Resolve", func(instances []interface{}) error {
+			*(instances[0].(*string)) = name
+			return nil
+		})
+	}
+	resolve(live, "host")
+	resolve(live, "bad")
+	if err := rec.Err(); nil != err {
+		t.Fatal(err)
+	}
+
+	records, err := ReadRecords(&buf)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if 2 != len(records) {
+		t.Fatalf("ReadRecords() = %+v", records)
+	}
+
+	fake := &service.Registry{}
+	if err := Replay(fake, "Resolver", &Resolver{}, records); nil != err {
+		t.Fatal(err)
+	}
+	if got, err := resolve(fake, "host"); nil != err || "10.0.0.1" != got {
+		t.Errorf("replayed Resolve(host) = %v, %v", got, err)
+	}
+	if _, err := resolve(fake, "bad"); nil == err || "unknown host" != err.Error() {
+		t.Errorf("replayed Resolve(bad) error = %v", err)
+	}
+	if _, err := resolve(fake, "other"); nil == err {
+		t.Errorf("replayed Resolve(other) did not fail")
+	}
+}
+
+type Echo struct{}
+
+func (e *Echo) Echo(s string) (string, error) {
+	return s, nil
+}
+
+func TestReplayNaming(t *testing.T) {
+	live := &service.Registry{}
+	live.SetNaming(nil, service.LowerCamelCaseNaming)
+	if err := live.Register(&Echo{}, ""); nil != err {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	NewRecorder(&buf).Record(live)
+
+	echo := func(r *service.Registry, s string) (interface{}, error) {
+		return r.Invoke(nil, "Echo.echo", func(instances []interface{}) error {
+			*(instances[0].(*string)) = s
+			return nil
+		})
+	}
+	if _, err := echo(live, "hello"); nil != err {
+		t.Fatal(err)
+	}
+	records, err := ReadRecords(&buf)
+	if nil != err || 1 != len(records) || "Echo.echo" != records[0].Method {
+		t.Fatalf("ReadRecords() = %+v, %v", records, err)
+	}
+
+	fake := &service.Registry{}
+	fake.SetNaming(nil, service.LowerCamelCaseNaming)
+	if err := Replay(fake, "Echo", &Echo{}, records); nil != err {
+		t.Fatal(err)
+	}
+	if got, err := echo(fake, "hello"); nil != err || "hello" != got {
+		t.Errorf("replayed Echo.echo(hello) = %v, %v", got, err)
+	}
+	if _, err := echo(fake, "other"); nil == err {
+		t.Errorf("replayed Echo.echo(other) did not fail")
+	}
+}
@@ -0,0 +1,356 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LOAFLE/util-go/ctx"
+)
+
+const (
+	// SpanKey is the ctx.Ctx attribute holding the SpanContext of the
+	// current call.
+	SpanKey = ctx.CtxKey("service.span")
+	// TraceParentKey is the ctx.Ctx attribute holding the SpanContext of the
+	// remote caller, set by transports from their metadata.
+	TraceParentKey = ctx.CtxKey("service.traceparent")
+
+	// TraceParentHeader is the HTTP header carrying the SpanContext of the
+	// caller in the format of W3C Trace Context.
+	TraceParentHeader = "traceparent"
+)
+
+// TraceID identifies a tree of calls.
+type TraceID [16]byte
+
+// SpanID identifies a call in a trace.
+type SpanID [8]byte
+
+// IsZero reports whether id is unset.
+func (id TraceID) IsZero() bool {
+	return TraceID{} == id
+}
+
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// MarshalText encodes id in hex, or as empty text if it is unset.
+func (id TraceID) MarshalText() ([]byte, error) {
+	if id.IsZero() {
+		return []byte{}, nil
+	}
+	return []byte(id.String()), nil
+}
+
+// UnmarshalText decodes id from hex, empty text is the unset id.
+func (id *TraceID) UnmarshalText(text []byte) error {
+	return unmarshalID(id[:], text)
+}
+
+// IsZero reports whether id is unset.
+func (id SpanID) IsZero() bool {
+	return SpanID{} == id
+}
+
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// MarshalText encodes id in hex, or as empty text if it is unset.
+func (id SpanID) MarshalText() ([]byte, error) {
+	if id.IsZero() {
+		return []byte{}, nil
+	}
+	return []byte(id.String()), nil
+}
+
+// UnmarshalText decodes id from hex, empty text is the unset id.
+func (id *SpanID) UnmarshalText(text []byte) error {
+	return unmarshalID(id[:], text)
+}
+
+func unmarshalID(id []byte, text []byte) error {
+	if 0 == len(text) {
+		for indexI := range id {
+			id[indexI] = 0
+		}
+		return nil
+	}
+	if hex.EncodedLen(len(id)) != len(text) {
+		return fmt.Errorf("ID %q must have %d hex digits", text, hex.EncodedLen(len(id)))
+	}
+	_, err := hex.Decode(id, text)
+	return err
+}
+
+// SpanContext identifies a span across calls.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+}
+
+// IsValid reports whether both IDs of sc are set.
+func (sc SpanContext) IsValid() bool {
+	return !sc.TraceID.IsZero() && !sc.SpanID.IsZero()
+}
+
+// TraceParent returns sc in the format of the traceparent header of W3C
+// Trace Context, e.g. "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01".
+func (sc SpanContext) TraceParent() string {
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-01"
+}
+
+// ParseTraceParent parses a traceparent header of W3C Trace Context.
+func ParseTraceParent(s string) (SpanContext, error) {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if 4 > len(parts) || !isHexByte(parts[0]) || "ff" == parts[0] || ("00" == parts[0] && 4 != len(parts)) ||
+		!isHexByte(parts[3]) {
+		return sc, fmt.Errorf("Invalid traceparent %q", s)
+	}
+	if err := sc.TraceID.UnmarshalText([]byte(parts[1])); nil != err {
+		return sc, fmt.Errorf("Invalid traceparent %q: %v", s, err)
+	}
+	if err := sc.SpanID.UnmarshalText([]byte(parts[2])); nil != err {
+		return sc, fmt.Errorf("Invalid traceparent %q: %v", s, err)
+	}
+	if !sc.IsValid() {
+		return sc, fmt.Errorf("Invalid traceparent %q", s)
+	}
+	return sc, nil
+}
+
+// isHexByte reports whether s is a byte in two lowercase hex digits.
+func isHexByte(s string) bool {
+	if 2 != len(s) {
+		return false
+	}
+	for indexI := 0; indexI < len(s); indexI++ {
+		if !('0' <= s[indexI] && s[indexI] <= '9' || 'a' <= s[indexI] && s[indexI] <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// SpanContextOf returns the SpanContext of the call c belongs to, ok is
+// false if the call is not traced.
+func SpanContextOf(c ctx.Ctx) (sc SpanContext, ok bool) {
+	if nil == c {
+		return sc, false
+	}
+	sc, ok = c.GetAttribute(SpanKey).(SpanContext)
+	return sc, ok
+}
+
+// parentOf returns the SpanContext of the caller of a call with c, which is
+// the current span of a nested call or the remote caller given by the
+// transport.
+func parentOf(c ctx.Ctx) (SpanContext, bool) {
+	if sc, ok := SpanContextOf(c); ok {
+		return sc, true
+	}
+	if nil == c {
+		return SpanContext{}, false
+	}
+	sc, ok := c.GetAttribute(TraceParentKey).(SpanContext)
+	return sc, ok && sc.IsValid()
+}
+
+// Span is a finished call of a method.
+type Span struct {
+	TraceID TraceID `json:"traceId"`
+	SpanID  SpanID  `json:"spanId"`
+	// ParentID is the span of the caller, unset for the root of a trace.
+	ParentID SpanID `json:"parentId"`
+	// Method is the name of the method as in "Service.Method".
+	Method string    `json:"method"`
+	Start  time.Time `json:"start"`
+	// Duration is the time taken by the call in nanoseconds.
+	Duration time.Duration `json:"duration"`
+	// Error is the message of the error returned by the call, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// SpanExporter receives the spans of finished calls.
+type SpanExporter interface {
+	Export(span Span)
+}
+
+// Trace adds exporters, which receive a Span of every call of Invoke.
+//
+// The span starts before the method is resolved, authorized, limited and
+// its parameters are decoded, so calls rejected by those checks are traced
+// with their error as well.
+// The span of a call is a child of the span stored in its ctx.Ctx, so that
+// methods passing their ctx.Ctx to Invoke build a call tree, or else of the
+// SpanContext given by the transport. Otherwise it starts a new trace.
+// The method gets a ctx.Ctx holding the SpanContext of its span.
+func (r *Registry) Trace(exporters ...SpanExporter) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	all := make([]SpanExporter, 0, len(r.exporters)+len(exporters))
+	all = append(all, r.exporters...)
+	all = append(all, exporters...)
+	r.exporters = all
+	r.publish()
+}
+
+// startSpan starts the span of a call of method with c and returns it with
+// the ctx.Ctx passed on to the call.
+func startSpan(c ctx.Ctx, method string) (*Span, ctx.Ctx) {
+	span := &Span{
+		SpanID: newSpanID(),
+		Method: method,
+		Start:  time.Now(),
+	}
+	if parent, ok := parentOf(c); ok {
+		span.TraceID = parent.TraceID
+		span.ParentID = parent.SpanID
+	} else {
+		span.TraceID = newTraceID()
+	}
+
+	spanCtx := ctx.NewCtx(c)
+	spanCtx.SetAttribute(SpanKey, SpanContext{TraceID: span.TraceID, SpanID: span.SpanID})
+	return span, spanCtx
+}
+
+// finish ends the span with the error of the call and exports it.
+func (span *Span) finish(err error, exporters []SpanExporter) {
+	span.Duration = time.Since(span.Start)
+	if nil != err {
+		span.Error = err.Error()
+	}
+	for _, exporter := range exporters {
+		exporter.Export(*span)
+	}
+}
+
+func newTraceID() (id TraceID) {
+	for id.IsZero() {
+		rand.Read(id[:])
+	}
+	return id
+}
+
+func newSpanID() (id SpanID) {
+	for id.IsZero() {
+		rand.Read(id[:])
+	}
+	return id
+}
+
+// MemorySpanExporter keeps the exported spans in memory.
+type MemorySpanExporter struct {
+	mutex sync.Mutex
+	spans []Span
+}
+
+// NewMemorySpanExporter returns an empty MemorySpanExporter.
+func NewMemorySpanExporter() *MemorySpanExporter {
+	return &MemorySpanExporter{}
+}
+
+// Export appends span.
+func (e *MemorySpanExporter) Export(span Span) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+// Spans returns the exported spans in the order in which the calls
+// finished, so children come before their parents.
+func (e *MemorySpanExporter) Spans() []Span {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	spans := make([]Span, len(e.spans))
+	copy(spans, e.spans)
+	return spans
+}
+
+// Children returns the exported spans whose parent is the span id.
+func (e *MemorySpanExporter) Children(id SpanID) []Span {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	var spans []Span
+	for _, span := range e.spans {
+		if id == span.ParentID {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+// Reset removes the exported spans.
+func (e *MemorySpanExporter) Reset() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.spans = nil
+}
+
+// JSONSpanExporter writes every span as a JSON object on its own line.
+// The first write error stops the export and is returned by Err and Close.
+type JSONSpanExporter struct {
+	mutex   sync.Mutex
+	w       io.Writer
+	encoder *json.Encoder
+	err     error
+}
+
+// NewJSONSpanExporter returns a JSONSpanExporter writing to w.
+func NewJSONSpanExporter(w io.Writer) *JSONSpanExporter {
+	return &JSONSpanExporter{
+		w:       w,
+		encoder: json.NewEncoder(w),
+	}
+}
+
+// OpenJSONSpanFile returns a JSONSpanExporter appending to the file at path,
+// which is created if it does not exist.
+func OpenJSONSpanFile(path string) (*JSONSpanExporter, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if nil != err {
+		return nil, err
+	}
+	return NewJSONSpanExporter(f), nil
+}
+
+// Export writes span.
+func (e *JSONSpanExporter) Export(span Span) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if nil != e.err {
+		return
+	}
+	e.err = e.encoder.Encode(&span)
+}
+
+// Err returns the first write error.
+func (e *JSONSpanExporter) Err() error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.err
+}
+
+// Close closes the underlying writer if it is an io.Closer and returns the
+// first error.
+func (e *JSONSpanExporter) Close() error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if closer, ok := e.w.(io.Closer); ok {
+		if err := closer.Close(); nil != err && nil == e.err {
+			e.err = err
+		}
+	}
+	return e.err
+}
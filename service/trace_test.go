@@ -0,0 +1,152 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/LOAFLE/util-go/ctx"
+)
+
+type Front struct {
+	Registry *Registry
+}
+
+func (f *Front) Call(c ctx.Ctx) (string, error) {
+	if _, err := f.Registry.Invoke(c, "Back.Work", nil); nil != err {
+		return "", err
+	}
+	_, err := f.Registry.Invoke(c, "Back.Fail", nil)
+	return "", err
+}
+
+type Back struct{}
+
+func (b *Back) Work(c ctx.Ctx) error {
+	if _, ok := SpanContextOf(c); !ok {
+		return errors.New("no span")
+	}
+	return nil
+}
+
+func (b *Back) Fail() error {
+	return errors.New("failed")
+}
+
+func TestParseTraceParent(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		wantErr bool
+	}{
+		{"valid", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false},
+		{"future version", "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false},
+		{"empty", "", true},
+		{"short trace", "00-4bf92f35-00f067aa0ba902b7-01", true},
+		{"zero trace", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", true},
+		{"not hex", "00-4bf92f3577b34da6a3ce929d0e0e473x-00f067aa0ba902b7-01", true},
+		{"extra field", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", true},
+		{"version not hex", "0x-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true},
+		{"flags not hex", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-0g", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, err := ParseTraceParent(tt.s)
+			if (nil != err) != tt.wantErr {
+				t.Fatalf("ParseTraceParent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01" != sc.TraceParent() {
+				t.Errorf("TraceParent() = %s", sc.TraceParent())
+			}
+		})
+	}
+}
+
+func TestTrace(t *testing.T) {
+	r := &Registry{}
+	if err := r.Register(&Front{Registry: r}, ""); nil != err {
+		t.Fatal(err)
+	}
+	if err := r.Register(&Back{}, ""); nil != err {
+		t.Fatal(err)
+	}
+	exporter := NewMemorySpanExporter()
+	r.Trace(exporter)
+
+	if _, err := r.Invoke(nil, "Front.Call", nil); nil == err {
+		t.Fatal("Invoke() error = nil")
+	}
+
+	spans := exporter.Spans()
+	if 3 != len(spans) {
+		t.Fatalf("Spans() = %v", spans)
+	}
+	work, fail, root := spans[0], spans[1], spans[2]
+	if "Front.Call" != root.Method || !root.ParentID.IsZero() {
+		t.Errorf("root = %+v", root)
+	}
+	if "Back.Work" != work.Method || "" != work.Error {
+		t.Errorf("work = %+v", work)
+	}
+	if "Back.Fail" != fail.Method || "failed" != fail.Error {
+		t.Errorf("fail = %+v", fail)
+	}
+	for _, span := range spans {
+		if root.TraceID != span.TraceID {
+			t.Errorf("span %s has trace %s, want %s", span.Method, span.TraceID, root.TraceID)
+		}
+	}
+	if children := exporter.Children(root.SpanID); 2 != len(children) {
+		t.Errorf("Children() = %v", children)
+	}
+
+	// Calls rejected before the method runs are traced too.
+	exporter.Reset()
+	if err := r.SetRoles("Back.Work", "admin"); nil != err {
+		t.Fatal(err)
+	}
+	r.Invoke(nil, "Back.Work", nil)
+	r.Invoke(nil, "Back.Missing", nil)
+	spans = exporter.Spans()
+	if 2 != len(spans) || "Back.Work" != spans[0].Method || "" == spans[0].Error ||
+		"Back.Missing" != spans[1].Method || "" == spans[1].Error {
+		t.Errorf("Spans() of rejected calls = %+v", spans)
+	}
+}
+
+func TestTraceHTTP(t *testing.T) {
+	r := &Registry{}
+	if err := r.Register(&Back{}, ""); nil != err {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	exporter := NewJSONSpanExporter(&buf)
+	r.Trace(exporter)
+
+	req := httptest.NewRequest(http.MethodPost, "/Back/Work", strings.NewReader(``))
+	req.Header.Set(TraceParentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	NewHTTPHandler(r).ServeHTTP(w, req)
+	if http.StatusOK != w.Code {
+		t.Fatalf("ServeHTTP() code = %d, body = %s", w.Code, w.Body.String())
+	}
+	if err := exporter.Close(); nil != err {
+		t.Fatal(err)
+	}
+
+	var span Span
+	if err := json.Unmarshal(buf.Bytes(), &span); nil != err {
+		t.Fatalf("%v: %s", err, buf.String())
+	}
+	if "4bf92f3577b34da6a3ce929d0e0e4736" != span.TraceID.String() || "00f067aa0ba902b7" != span.ParentID.String() {
+		t.Errorf("span = %+v", span)
+	}
+	if "Back.Work" != span.Method || span.SpanID.IsZero() {
+		t.Errorf("span = %+v", span)
+	}
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// injection is a field of a receiver which is filled with the receiver of
+// another service, declared by a `service:"Name"` tag.
+type injection struct {
+	field   string       // Go name of the field
+	index   int          // index of the field
+	t       reflect.Type // type of the field
+	service string       // name of the injected service
+}
+
+// injectionsOf returns the tagged fields of the receiver of s.
+func injectionsOf(s *ServiceMeta) ([]injection, error) {
+	t := s.rcvrT
+	if reflect.Ptr != t.Kind() || reflect.Struct != t.Elem().Kind() {
+		return nil, nil
+	}
+	t = t.Elem()
+
+	var injections []injection
+	for indexI := 0; indexI < t.NumField(); indexI++ {
+		sf := t.Field(indexI)
+		name, ok := sf.Tag.Lookup("service")
+		if !ok {
+			continue
+		}
+		if "" != sf.PkgPath {
+			return nil, fmt.Errorf("Registry: field %q of %q is not exported", sf.Name, s.name)
+		}
+		if "" == name {
+			return nil, fmt.Errorf("Registry: field %q of %q has no service name", sf.Name, s.name)
+		}
+		injections = append(injections, injection{
+			field:   sf.Name,
+			index:   indexI,
+			t:       sf.Type,
+			service: name,
+		})
+	}
+	return injections, nil
+}
+
+// checkInjection returns an error if the receiver of target can not be
+// assigned to the field of s given by inj.
+func (s *ServiceMeta) checkInjection(inj injection, target *ServiceMeta) error {
+	if !target.rcvrT.AssignableTo(inj.t) {
+		return fmt.Errorf("Registry: service %q of type %s can not be assigned to field %q of %q of type %s",
+			target.name, target.rcvrT, inj.field, s.name, inj.t)
+	}
+	return nil
+}
+
+// inject sets the field of s given by inj to the receiver of target, which
+// must have been checked with checkInjection. A field which already holds
+// target is not written again.
+func (s *ServiceMeta) inject(inj injection, target *ServiceMeta) {
+	field := s.rcvrV.Elem().Field(inj.index)
+	if target.rcvrT.Comparable() && !field.IsZero() && field.Interface() == target.rcvrV.Interface() {
+		return
+	}
+	field.Set(target.rcvrV)
+}
+
+// wire fills the fields of s, which is not published yet, with the
+// registered services. Either all of those fields are filled or, on error,
+// none.
+// Fields of the registered services which wait for s are not filled, as
+// those services may be serving calls; Wire fills them.
+// The caller must hold the write lock of mutex.
+func (r *Registry) wire(s *ServiceMeta) error {
+	for _, inj := range s.injections {
+		if target, ok := r.services[inj.service]; ok {
+			if err := s.checkInjection(inj, target); nil != err {
+				return err
+			}
+		}
+	}
+	for _, inj := range s.injections {
+		if target, ok := r.services[inj.service]; ok {
+			s.inject(inj, target)
+		}
+	}
+	return nil
+}
+
+// Wire fills the fields tagged with `service:"Name"` of all registered
+// services with the receivers of the named services.
+//
+// Register fills the fields of a new service whose services are already
+// registered, but not the fields of services registered before the ones
+// they need. Wire fills those and checks that nothing is missing. It fails
+// if a service is not registered or if services depend on each other in a
+// cycle; then no field is written. StartAll calls Wire before it starts any
+// service.
+//
+// Wire writes the fields of the receivers without synchronization, so it
+// must be called before the services serve calls. Fields which are already
+// filled are not written again, but fields of live services must not be
+// rewired to other services.
+func (r *Registry) Wire() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	names := make([]string, 0, len(r.services))
+	for name := range r.services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		s := r.services[name]
+		for _, inj := range s.injections {
+			target, ok := r.services[inj.service]
+			if !ok {
+				return fmt.Errorf("Registry: field %q of %q needs unknown service %q", inj.field, name, inj.service)
+			}
+			if err := s.checkInjection(inj, target); nil != err {
+				return err
+			}
+		}
+	}
+	if _, err := r.startOrder(); nil != err {
+		return err
+	}
+
+	for _, name := range names {
+		s := r.services[name]
+		for _, inj := range s.injections {
+			s.inject(inj, r.services[inj.service])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+type Repository struct{}
+
+func (r *Repository) Find() (string, error) { return "found", nil }
+
+type Finder interface {
+	Find() (string, error)
+}
+
+type Probe struct {
+	Repository *Repository `service:"Repository"`
+	Finder     Finder      `service:"Repository"`
+}
+
+func (p *Probe) Find() (string, error) { return p.Repository.Find() }
+
+type Ping struct {
+	Pong *Pong `service:"Pong"`
+}
+
+func (p *Ping) Run() error { return nil }
+
+type Pong struct {
+	Ping *Ping `service:"Ping"`
+}
+
+func (p *Pong) Run() error { return nil }
+
+type MistypedProbe struct {
+	Repository *Probe `service:"Repository"`
+}
+
+func (p *MistypedProbe) Run() error { return nil }
+
+func TestRegistryWire(t *testing.T) {
+	// The dependent service is registered first and filled later.
+	r := &Registry{}
+	probe := &Probe{}
+	if err := r.Register(probe, ""); nil != err {
+		t.Fatal(err)
+	}
+	if err := r.Wire(); nil == err || !strings.Contains(err.Error(), `unknown service "Repository"`) {
+		t.Errorf("Wire() error = %v, want unknown service", err)
+	}
+
+	repository := &Repository{}
+	if err := r.Register(repository, ""); nil != err {
+		t.Fatal(err)
+	}
+	// The registered service may be serving calls, so Register leaves it.
+	if nil != probe.Repository || nil != probe.Finder {
+		t.Errorf("Register() filled fields of a registered service: %+v", probe)
+	}
+	if err := r.Wire(); nil != err {
+		t.Errorf("Wire() error = %v", err)
+	}
+	if probe.Repository != repository || probe.Finder != repository {
+		t.Errorf("Wire() did not fill fields: %+v", probe)
+	}
+
+	// A new service is filled by Register.
+	later := &Probe{}
+	if err := r.Register(later, "Later"); nil != err {
+		t.Fatal(err)
+	}
+	if later.Repository != repository || later.Finder != repository {
+		t.Errorf("Register() did not fill fields: %+v", later)
+	}
+	order, err := r.StartOrder()
+	if nil != err {
+		t.Fatal(err)
+	}
+	if "Repository,Later,Probe" != strings.Join(order, ",") {
+		t.Errorf("StartOrder() = %v", order)
+	}
+}
+
+func TestRegistryWireErrors(t *testing.T) {
+	r := &Registry{}
+	if err := r.Register(&Ping{}, ""); nil != err {
+		t.Fatal(err)
+	}
+	if err := r.Register(&Pong{}, ""); nil != err {
+		t.Fatal(err)
+	}
+	if err := r.StartAll(); nil == err || !strings.Contains(err.Error(), "circular dependency") {
+		t.Errorf("StartAll() error = %v, want circular dependency", err)
+	}
+
+	r = &Registry{}
+	if err := r.Register(&Repository{}, ""); nil != err {
+		t.Fatal(err)
+	}
+	if err := r.Register(&MistypedProbe{}, ""); nil == err || !strings.Contains(err.Error(), "can not be assigned") {
+		t.Errorf("Register() error = %v, want can not be assigned", err)
+	}
+
+	// No field is filled if one of them fails.
+	half := &HalfProbe{}
+	if err := r.Register(half, ""); nil == err {
+		t.Errorf("Register() of HalfProbe succeeded")
+	}
+	if nil != half.Repository {
+		t.Errorf("Register() filled fields of a rejected service: %+v", half)
+	}
+}
+
+type HalfProbe struct {
+	Repository *Repository `service:"Repository"`
+	Probe      *Probe      `service:"Repository"`
+}
+
+func (p *HalfProbe) Run() error { return nil }